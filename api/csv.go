@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fahy.xyz/xcontestextractor/stats"
+	"fahy.xyz/xcontestextractor/store"
+)
+
+// flightColumns are the columns of the flights in CSV.
+var flightColumns = []string{
+	"id", "url", "pilot_handle", "full_name", "flight_date", "country_code", "take_off", "flight_type",
+	"distance", "points", "flight_duration_seconds", "average_speed", "altitude_max", "glider",
+}
+
+// pilotColumns are the columns of a pilot in CSV, without the flights by country.
+var pilotColumns = []string{
+	"handle", "full_name", "home_country", "first_seen", "last_seen", "flight_count", "total_distance",
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// formatDate formats a date in milliseconds, e.g. `2021-12-05`.
+func formatDate(millis int64) string {
+	return time.UnixMilli(millis).UTC().Format(dateLayout)
+}
+
+// writeCSV writes the header and the rows as CSV.
+func writeCSV(w http.ResponseWriter, header []string, rows [][]string) {
+	w.Header().Set("Content-Type", "text/csv")
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		log.Errorf("Error writing response: %v", err)
+		return
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		log.Errorf("Error writing response: %v", err)
+	}
+}
+
+func writeFlightsCSV(w http.ResponseWriter, flights []store.FlightHit) {
+	rows := make([][]string, len(flights))
+	for i, hit := range flights {
+		flight := hit.Flight
+		rows[i] = []string{
+			hit.Id,
+			flight.Url,
+			flight.PilotHandle,
+			flight.FullName,
+			formatDate(flight.FlightDate),
+			flight.CountryCode,
+			flight.TakeOff,
+			flight.FlightType,
+			formatFloat(flight.Distance),
+			formatFloat(flight.Points),
+			strconv.FormatInt(flight.FlightDurationSeconds, 10),
+			formatFloat(flight.AverageSpeed),
+			strconv.FormatInt(flight.AltitudeMax, 10),
+			flight.Glider,
+		}
+	}
+	writeCSV(w, flightColumns, rows)
+}
+
+func writePilotCSV(w http.ResponseWriter, pilot *store.Pilot) {
+	writeCSV(w, pilotColumns, [][]string{{
+		pilot.Handle,
+		pilot.FullName,
+		pilot.HomeCountry,
+		formatDate(pilot.FirstSeen),
+		formatDate(pilot.LastSeen),
+		strconv.Itoa(pilot.FlightCount),
+		formatFloat(pilot.TotalDistance),
+	}})
+}
+
+func writeWeeklyStatsCSV(w http.ResponseWriter, weeklyStats []store.WeeklyStats) {
+	w.Header().Set("Content-Type", "text/csv")
+	if err := stats.WriteCSV(w, weeklyStats); err != nil {
+		log.Errorf("Error writing response: %v", err)
+	}
+}
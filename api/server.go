@@ -0,0 +1,333 @@
+// Package api serves the indexed flights, the pilots and the weekly statistics over HTTP.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"fahy.xyz/xcontestextractor/parser"
+	"fahy.xyz/xcontestextractor/store"
+	"github.com/sqooba/go-common/logging"
+)
+
+const (
+	// Format of the dates of the parameters.
+	dateLayout string = "2006-01-02"
+	// DefaultPageSize is the number of flights of a page when the size is not set.
+	DefaultPageSize int = 20
+	// FormatJSON and FormatCSV are the formats of the responses, selected with the `format` parameter.
+	FormatJSON string = "json"
+	FormatCSV  string = "csv"
+)
+
+var (
+	log = logging.NewLogger()
+)
+
+// Store is the storage queried by the API.
+type Store interface {
+	// SearchFlights returns a page of the flights matching the query, the latest first.
+	SearchFlights(ctx context.Context, query store.FlightQuery) (*store.FlightPage, error)
+	// GetFlight returns the flight with the given id, store.ErrNotFound if it does not exist.
+	GetFlight(ctx context.Context, id string) (*parser.Flight, error)
+	// GetPilot returns the pilot with the given handle, store.ErrNotFound if it does not exist.
+	GetPilot(ctx context.Context, handle string) (*store.Pilot, error)
+	// WeeklyStats computes the statistics of the flights matching the query by year, week and country.
+	WeeklyStats(ctx context.Context, query store.FlightQuery) ([]store.WeeklyStats, error)
+}
+
+// Server handles the requests of the API.
+type Server struct {
+	store Store
+	// Maximal number of flights of a page.
+	maxPageSize int
+}
+
+// NewServer creates a new instance of the Server.
+func NewServer(store Store, maxPageSize int) *Server {
+	if maxPageSize < 1 {
+		maxPageSize = DefaultPageSize
+	}
+	return &Server{store: store, maxPageSize: maxPageSize}
+}
+
+// Register adds the endpoints of the API to the mux:
+//
+//   - `/flights`: the flights matching the filters, paginated with `offset` and `size`.
+//   - `/flights/{id}`: a single flight.
+//   - `/pilots/{handle}`: a single pilot.
+//   - `/stats/weekly`: the statistics of the flights matching the filters by year, week and country.
+//
+// The filters are `from` and `to` (e.g. `2022-05-01`), `country`, `type`, `pilot`, `min_distance` and `max_distance`.
+// The responses are in JSON, or in CSV with `format=csv`.
+func (s *Server) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/flights", s.handleFlights)
+	mux.HandleFunc("/flights/", s.handleFlight)
+	mux.HandleFunc("/pilots/", s.handlePilot)
+	mux.HandleFunc("/stats/weekly", s.handleWeeklyStats)
+}
+
+// httpError is an error with the status of the response.
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+// badRequest returns an error for an invalid parameter.
+func badRequest(format string, a ...interface{}) error {
+	return &httpError{status: http.StatusBadRequest, err: fmt.Errorf(format, a...)}
+}
+
+// writeError writes an error as JSON with the status matching the error.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var statusErr *httpError
+	switch {
+	case errors.As(err, &statusErr):
+		status = statusErr.status
+	case errors.Is(err, store.ErrNotFound):
+		status = http.StatusNotFound
+	}
+	if status == http.StatusInternalServerError {
+		log.Errorf("Error handling request: %v", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// writeJSON writes the value as JSON.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("Error writing response: %v", err)
+	}
+}
+
+// parseFormat returns the format of the response, JSON by default.
+func parseFormat(values url.Values) (string, error) {
+	switch format := strings.ToLower(values.Get("format")); format {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	default:
+		return "", badRequest("unknown format %q", format)
+	}
+}
+
+// parseDate converts a date parameter into milliseconds, the end of the day if end is set.
+func parseDate(values url.Values, name string, end bool) (int64, error) {
+	value := values.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return 0, badRequest("invalid date %s=%q, expected YYYY-MM-DD", name, value)
+	}
+	if end {
+		date = date.AddDate(0, 0, 1).Add(-time.Millisecond)
+	}
+	return date.UnixMilli(), nil
+}
+
+// parseFloat converts a number parameter, 0 if it is not set.
+func parseFloat(values url.Values, name string) (float64, error) {
+	value := values.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, badRequest("invalid number %s=%q", name, value)
+	}
+	return number, nil
+}
+
+// parseInt converts an integer parameter, the default value if it is not set.
+func parseInt(values url.Values, name string, defaultValue int) (int, error) {
+	value := values.Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		return 0, badRequest("invalid integer %s=%q", name, value)
+	}
+	return number, nil
+}
+
+// parseQuery converts the parameters of a request into a query.
+func (s *Server) parseQuery(values url.Values) (store.FlightQuery, error) {
+	query := store.FlightQuery{
+		Country:     strings.ToUpper(values.Get("country")),
+		FlightType:  values.Get("type"),
+		PilotHandle: values.Get("pilot"),
+	}
+	var err error
+	if query.From, err = parseDate(values, "from", false); err != nil {
+		return query, err
+	}
+	if query.To, err = parseDate(values, "to", true); err != nil {
+		return query, err
+	}
+	if query.MinDistance, err = parseFloat(values, "min_distance"); err != nil {
+		return query, err
+	}
+	if query.MaxDistance, err = parseFloat(values, "max_distance"); err != nil {
+		return query, err
+	}
+	if query.Offset, err = parseInt(values, "offset", 0); err != nil {
+		return query, err
+	}
+	if query.Size, err = parseInt(values, "size", DefaultPageSize); err != nil {
+		return query, err
+	}
+	if query.Size > s.maxPageSize {
+		return query, badRequest("size %d is above the maximum of %d", query.Size, s.maxPageSize)
+	}
+	return query, nil
+}
+
+// checkMethod returns an error if the method of the request is not GET.
+func checkMethod(r *http.Request) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return &httpError{status: http.StatusMethodNotAllowed, err: fmt.Errorf("method %s not allowed", r.Method)}
+	}
+	return nil
+}
+
+// pathParameter returns the part of the path after the prefix, e.g. the id of `/flights/{id}`.
+func pathParameter(r *http.Request, prefix string) (string, error) {
+	value := strings.TrimPrefix(r.URL.Path, prefix)
+	if value == "" || strings.Contains(value, "/") {
+		return "", &httpError{status: http.StatusNotFound, err: fmt.Errorf("unknown path %s", r.URL.Path)}
+	}
+	return value, nil
+}
+
+func (s *Server) handleFlights(w http.ResponseWriter, r *http.Request) {
+	if err := checkMethod(r); err != nil {
+		writeError(w, err)
+		return
+	}
+	values := r.URL.Query()
+	format, err := parseFormat(values)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	query, err := s.parseQuery(values)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	page, err := s.store.SearchFlights(r.Context(), query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if format == FormatCSV {
+		writeFlightsCSV(w, page.Flights)
+		return
+	}
+	writeJSON(w, page)
+}
+
+func (s *Server) handleFlight(w http.ResponseWriter, r *http.Request) {
+	if err := checkMethod(r); err != nil {
+		writeError(w, err)
+		return
+	}
+	id, err := pathParameter(r, "/flights/")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	format, err := parseFormat(r.URL.Query())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	flight, err := s.store.GetFlight(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	hit := store.FlightHit{Id: id, Flight: flight}
+	if format == FormatCSV {
+		writeFlightsCSV(w, []store.FlightHit{hit})
+		return
+	}
+	writeJSON(w, hit)
+}
+
+func (s *Server) handlePilot(w http.ResponseWriter, r *http.Request) {
+	if err := checkMethod(r); err != nil {
+		writeError(w, err)
+		return
+	}
+	handle, err := pathParameter(r, "/pilots/")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	format, err := parseFormat(r.URL.Query())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	pilot, err := s.store.GetPilot(r.Context(), handle)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if format == FormatCSV {
+		writePilotCSV(w, pilot)
+		return
+	}
+	writeJSON(w, pilot)
+}
+
+func (s *Server) handleWeeklyStats(w http.ResponseWriter, r *http.Request) {
+	if err := checkMethod(r); err != nil {
+		writeError(w, err)
+		return
+	}
+	values := r.URL.Query()
+	format, err := parseFormat(values)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	query, err := s.parseQuery(values)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	stats, err := s.store.WeeklyStats(r.Context(), query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if format == FormatCSV {
+		writeWeeklyStatsCSV(w, stats)
+		return
+	}
+	if stats == nil {
+		stats = []store.WeeklyStats{}
+	}
+	writeJSON(w, stats)
+}
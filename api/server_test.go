@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fahy.xyz/xcontestextractor/parser"
+	"fahy.xyz/xcontestextractor/store"
+)
+
+// fakeStore records the last query and returns fixed results.
+type fakeStore struct {
+	query store.FlightQuery
+	err   error
+}
+
+var testFlight = &parser.Flight{
+	Url:         "https://www.xcontest.org/world/en/flights/detail:Nicober/7.11.2021/13:13",
+	PilotHandle: "Nicober",
+	FullName:    "Nicolas Bernard",
+	FlightDate:  time.Date(2021, 11, 7, 0, 0, 0, 0, time.UTC).UnixMilli(),
+	CountryCode: "CH",
+	FlightType:  "free_flight",
+	Distance:    52.5,
+}
+
+func (s *fakeStore) SearchFlights(_ context.Context, query store.FlightQuery) (*store.FlightPage, error) {
+	s.query = query
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &store.FlightPage{
+		Total:   1,
+		Offset:  query.Offset,
+		Size:    query.Size,
+		Flights: []store.FlightHit{{Id: "abc", Flight: testFlight}},
+	}, nil
+}
+
+func (s *fakeStore) GetFlight(_ context.Context, id string) (*parser.Flight, error) {
+	if id != "abc" {
+		return nil, store.ErrNotFound
+	}
+	return testFlight, nil
+}
+
+func (s *fakeStore) GetPilot(_ context.Context, handle string) (*store.Pilot, error) {
+	if handle != "Nicober" {
+		return nil, store.ErrNotFound
+	}
+	return &store.Pilot{Handle: "Nicober", FullName: "Nicolas Bernard", FlightCount: 3}, nil
+}
+
+func (s *fakeStore) WeeklyStats(_ context.Context, query store.FlightQuery) ([]store.WeeklyStats, error) {
+	s.query = query
+	return []store.WeeklyStats{{Year: 2021, Week: 44, Country: "CH", Flights: 3, DistanceAvg: 40, CountAbove50km: 1}}, nil
+}
+
+func serve(t *testing.T, fake *fakeStore, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	NewServer(fake, 100).Register(mux)
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	return recorder
+}
+
+func TestSearchFlights(t *testing.T) {
+	fake := &fakeStore{}
+	res := serve(t, fake, "/flights?from=2021-11-01&to=2021-11-07&country=ch&type=free_flight&pilot=Nicober&min_distance=50&offset=20&size=10")
+	if res.Code != http.StatusOK {
+		t.Fatalf("Wrong status %d: %s", res.Code, res.Body)
+	}
+	expected := store.FlightQuery{
+		From:        time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC).UnixMilli(),
+		To:          time.Date(2021, 11, 8, 0, 0, 0, 0, time.UTC).UnixMilli() - 1,
+		Country:     "CH",
+		FlightType:  "free_flight",
+		PilotHandle: "Nicober",
+		MinDistance: 50,
+		Offset:      20,
+		Size:        10,
+	}
+	if fake.query != expected {
+		t.Errorf("Wrong query: %+v", fake.query)
+	}
+	var page store.FlightPage
+	if err := json.Unmarshal(res.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Error decoding the response: %v", err)
+	}
+	if page.Total != 1 || len(page.Flights) != 1 || page.Flights[0].Id != "abc" || page.Flights[0].PilotHandle != "Nicober" {
+		t.Errorf("Wrong page: %+v", page)
+	}
+}
+
+func TestSearchFlightsDefaultSize(t *testing.T) {
+	fake := &fakeStore{}
+	if res := serve(t, fake, "/flights"); res.Code != http.StatusOK {
+		t.Fatalf("Wrong status %d: %s", res.Code, res.Body)
+	}
+	if fake.query.Size != DefaultPageSize || fake.query.Offset != 0 {
+		t.Errorf("Wrong pagination: %+v", fake.query)
+	}
+}
+
+func TestSearchFlightsCSV(t *testing.T) {
+	res := serve(t, &fakeStore{}, "/flights?format=csv")
+	if res.Code != http.StatusOK {
+		t.Fatalf("Wrong status %d: %s", res.Code, res.Body)
+	}
+	if contentType := res.Header().Get("Content-Type"); contentType != "text/csv" {
+		t.Errorf("Wrong content type %s", contentType)
+	}
+	lines := strings.Split(strings.TrimSpace(res.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Wrong number of lines: %v", lines)
+	}
+	if !strings.HasPrefix(lines[0], "id,url,pilot_handle") {
+		t.Errorf("Wrong header %s", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "abc,"+testFlight.Url+",Nicober,Nicolas Bernard,2021-11-07,CH,") {
+		t.Errorf("Wrong row %s", lines[1])
+	}
+}
+
+func TestInvalidParameters(t *testing.T) {
+	for _, target := range []string{
+		"/flights?from=07.11.2021",
+		"/flights?min_distance=abc",
+		"/flights?offset=-1",
+		"/flights?size=101",
+		"/flights?format=xml",
+		"/stats/weekly?to=2021",
+	} {
+		res := serve(t, &fakeStore{}, target)
+		if res.Code != http.StatusBadRequest {
+			t.Errorf("Wrong status %d for %s", res.Code, target)
+		}
+		var body map[string]string
+		if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil || body["error"] == "" {
+			t.Errorf("Missing error for %s: %s", target, res.Body)
+		}
+	}
+}
+
+func TestStoreError(t *testing.T) {
+	res := serve(t, &fakeStore{err: errors.New("unavailable")}, "/flights")
+	if res.Code != http.StatusInternalServerError {
+		t.Errorf("Wrong status %d", res.Code)
+	}
+}
+
+func TestGetFlight(t *testing.T) {
+	res := serve(t, &fakeStore{}, "/flights/abc")
+	if res.Code != http.StatusOK {
+		t.Fatalf("Wrong status %d: %s", res.Code, res.Body)
+	}
+	var hit store.FlightHit
+	if err := json.Unmarshal(res.Body.Bytes(), &hit); err != nil {
+		t.Fatalf("Error decoding the response: %v", err)
+	}
+	if hit.Id != "abc" || hit.Url != testFlight.Url {
+		t.Errorf("Wrong flight: %+v", hit)
+	}
+	for _, target := range []string{"/flights/unknown", "/flights/abc/track", "/pilots/unknown", "/pilots/"} {
+		if res := serve(t, &fakeStore{}, target); res.Code != http.StatusNotFound {
+			t.Errorf("Wrong status %d for %s", res.Code, target)
+		}
+	}
+}
+
+func TestGetPilot(t *testing.T) {
+	res := serve(t, &fakeStore{}, "/pilots/Nicober")
+	if res.Code != http.StatusOK {
+		t.Fatalf("Wrong status %d: %s", res.Code, res.Body)
+	}
+	var pilot store.Pilot
+	if err := json.Unmarshal(res.Body.Bytes(), &pilot); err != nil {
+		t.Fatalf("Error decoding the response: %v", err)
+	}
+	if pilot.Handle != "Nicober" || pilot.FlightCount != 3 {
+		t.Errorf("Wrong pilot: %+v", pilot)
+	}
+}
+
+func TestWeeklyStats(t *testing.T) {
+	fake := &fakeStore{}
+	res := serve(t, fake, "/stats/weekly?country=CH&format=csv")
+	if res.Code != http.StatusOK {
+		t.Fatalf("Wrong status %d: %s", res.Code, res.Body)
+	}
+	if fake.query.Country != "CH" {
+		t.Errorf("Wrong query: %+v", fake.query)
+	}
+	expected := "year,week,country,flights,distance_avg,distance_med,count_above_50km,count_above_100km,count_above_150km,count_above_200km\n" +
+		"2021,44,CH,3,40,0,1,0,0,0\n"
+	if res.Body.String() != expected {
+		t.Errorf("Wrong CSV:\n%s", res.Body)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	mux := http.NewServeMux()
+	NewServer(&fakeStore{}, 100).Register(mux)
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/flights", nil))
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Wrong status %d", res.Code)
+	}
+}
@@ -0,0 +1,379 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fahy.xyz/xcontestextractor/deadletter"
+	"fahy.xyz/xcontestextractor/igc"
+	"fahy.xyz/xcontestextractor/metrics"
+	"fahy.xyz/xcontestextractor/pagesource"
+	"fahy.xyz/xcontestextractor/parser"
+	"fahy.xyz/xcontestextractor/store"
+	"github.com/sqooba/go-common/logging"
+)
+
+const (
+	// Source of the flights extracted from the archive.
+	Source        string = "archive"
+	flightsByPage int    = 100
+)
+
+var (
+	log = logging.NewLogger()
+)
+
+// Config is the configuration of the extraction of the archive.
+type Config struct {
+	// Number of flights processed concurrently.
+	Parallelism int
+	// Number of attempts before giving up a flight.
+	MaxAttempts int
+	// Number of empty pages before considering there is no more flight.
+	NumberOfRetries int
+	// Interval between two pages to avoid doing too many requests.
+	Interval time.Duration
+}
+
+// Extractor processes the flights of the pages of the archive.
+type Extractor struct {
+	manager     store.FlightStore
+	fetcher     *parser.Fetcher
+	pages       pagesource.Source
+	deadLetters *deadletter.Writer
+	config      Config
+	// Downloader of the IGC tracks, nil if disabled.
+	Tracks *igc.Downloader
+}
+
+// NewExtractor creates a new instance of the Extractor.
+func NewExtractor(manager store.FlightStore, fetcher *parser.Fetcher, pages pagesource.Source, deadLetters *deadletter.Writer, config Config) *Extractor {
+	return &Extractor{
+		manager:     manager,
+		fetcher:     fetcher,
+		pages:       pages,
+		deadLetters: deadLetters,
+		config:      config,
+	}
+}
+
+// Extract processes all the pages of the archive starting at the checkpoint, until there is no more flight.
+//
+// The url ends with the start parameter of the page, e.g. `.../flights/#flights[start]=`.
+// The checkpoint is saved after each page and marked as complete once a page without any flight is reached
+// and the failed flights are recovered or given up. A page which cannot be downloaded is retried like an empty
+// page, then returned as an error, the checkpoint staying on it to resume from it.
+// The error of the context is returned if it is cancelled, the page in progress is then processed again on the next start.
+func (e *Extractor) Extract(ctx context.Context, url string, state *store.DownloadState) error {
+	flightNumber := state.LastFlightNumber
+	retry := 0
+	for ctx.Err() == nil {
+		metrics.RunsTotal.Inc()
+		pageUrl := url + strconv.Itoa(flightNumber)
+		log.Infof("Extracting: %s", pageUrl)
+
+		data, err := e.pages.GetPage(ctx, pageUrl)
+		metrics.HttpRequestsTotal.Inc()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			// The page is retried like an empty page, the page source delaying the next request with its backoff.
+			metrics.ErrorsTotal.Inc()
+			log.Errorf("Error getting the page %s: %v", pageUrl, err)
+			if retry < e.config.NumberOfRetries {
+				retry++
+				continue
+			}
+			return fmt.Errorf("error getting the page %s after %d retries: %w", pageUrl, retry, err)
+		}
+
+		entries, err := parser.ParseFlightsTable(strings.NewReader(data))
+		// Rows which cannot be parsed cannot be retried, they are only counted in the page status.
+		var rowErrors parser.RowErrors
+		if errors.As(err, &rowErrors) {
+			for _, rowError := range rowErrors {
+				metrics.ErrorsTotal.Inc()
+				log.Errorf("Error parsing the flights table: %v", rowError)
+			}
+		} else if err != nil {
+			return fmt.Errorf("error parsing the flights table of %s: %w", pageUrl, err)
+		}
+
+		// If the page has no flight, retry before quitting.
+		if len(entries)+len(rowErrors) == 0 {
+			log.Infof("No more flight to insert (flight number=%d)", flightNumber)
+			metrics.ErrorsTotal.Inc()
+			if retry < e.config.NumberOfRetries {
+				retry++
+				continue
+			}
+			break
+		}
+		// Reset the retry counter if we get a non-empty page.
+		retry = 0
+
+		// Flights of the page, inserted in a single bulk request.
+		flights, failed := e.processEntries(ctx, entries)
+		if ctx.Err() != nil {
+			// The page is only partially processed, it will be processed again on the next start.
+			break
+		}
+		failedInserts, err := e.insertFlights(flights, entries)
+		if err != nil {
+			return err
+		}
+		failed = append(failed, failedInserts...)
+
+		page := store.PageState{
+			Start:     flightNumber,
+			Status:    store.PageComplete,
+			Flights:   len(entries) + len(rowErrors),
+			Failed:    len(failed) + len(rowErrors),
+			UpdatedAt: time.Now().UnixMilli(),
+		}
+		if page.Failed > 0 {
+			page.Status = store.PagePartial
+			log.Errorf("%d flights failed on page %d", page.Failed, flightNumber)
+		}
+		state.SetPage(page)
+
+		// The checkpoint only moves once all the flights of the page are committed or kept to be retried.
+		flightNumber += flightsByPage
+		state.LastFlightNumber = flightNumber
+		e.saveState(state)
+
+		// The flights which failed before this page are retried once per page, after the interval.
+		if err = e.retryAfterInterval(ctx, state); err != nil {
+			return err
+		}
+		state.FailedFlights = append(state.FailedFlights, failed...)
+		metrics.FailedFlights.Set(float64(len(state.FailedFlights)))
+		e.saveState(state)
+	}
+	// The flights still failing at the end of the archive are retried at the same pace until they are recovered
+	// or sent to the dead letters, a complete state having no flight left to retry.
+	for len(state.FailedFlights) > 0 && ctx.Err() == nil {
+		if err := e.retryAfterInterval(ctx, state); err != nil {
+			return err
+		}
+		e.saveState(state)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	state.Complete = true
+	e.saveState(state)
+	return nil
+}
+
+// retryAfterInterval waits for the interval between two pages, then retries the flights which failed.
+func (e *Extractor) retryAfterInterval(ctx context.Context, state *store.DownloadState) error {
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-time.After(e.config.Interval):
+	}
+	if len(state.FailedFlights) == 0 {
+		return nil
+	}
+	if err := e.retryFailedFlights(ctx, state); err != nil {
+		return err
+	}
+	metrics.FailedFlights.Set(float64(len(state.FailedFlights)))
+	return nil
+}
+
+// saveState saves the checkpoint, a failure is only logged as the checkpoint is saved again after the next page.
+func (e *Extractor) saveState(state *store.DownloadState) {
+	if err := e.manager.SetDownloadState(state); err != nil {
+		metrics.ErrorsTotal.Inc()
+		log.Errorf("Error while saving the download state: %v", err)
+	}
+}
+
+// processEntry retrieves the information of the flight of an entry.
+//
+// Nil is returned if the flight already exists.
+func (e *Extractor) processEntry(ctx context.Context, entry parser.Entry) (*parser.Flight, error) {
+	log.Debugf("Entry to check: %+v", entry)
+	// Check if the flight exists.
+	flightExists, err := e.manager.FlightExists(entry.Link)
+	if err != nil {
+		metrics.ErrorsTotal.Inc()
+		log.Errorf("Error searching if the flight exists: %v", err)
+	}
+	if flightExists {
+		log.Info("Flight already exists, skipping.")
+		metrics.DuplicatesTotal.Inc()
+		return nil, nil
+	}
+	log.Debugf("Getting flight info of %s at %d (%f km)", entry.FullName, entry.FlightDate, entry.Distance)
+	flight, err := e.fetcher.GetFlightInfo(ctx, entry.Link, Source)
+	metrics.HttpRequestsTotal.Inc()
+	if err != nil {
+		return nil, err
+	}
+
+	flight.FullName = entry.FullName
+	flight.FlightDate = entry.FlightDate
+	flight.Distance = entry.Distance
+	flight.FlightType = entry.FlightType
+	//flight.PublicationDate = publicationDate.UnixMilli()
+	// TODO: what to put as publication date
+	flight.Url = entry.Link
+	// The time zone of the take-off is also displayed in the archive.
+	if flight.UtcOffset == "" && entry.UtcOffset != "" {
+		if err = flight.SetLaunchTime(entry.Link, entry.UtcOffset); err != nil {
+			log.Warningf("Error setting the launch time of %s: %v", entry.Link, err)
+		}
+	}
+	if e.Tracks != nil {
+		e.Tracks.ProcessOrSkip(ctx, flight)
+	}
+
+	log.Debugf("Flight to insert: %+v", flight)
+	return flight, nil
+}
+
+// writeDeadLetter saves a flight which cannot be extracted.
+func (e *Extractor) writeDeadLetter(entry parser.Entry, err error) {
+	metrics.DeadLettersTotal.Inc()
+	if err := e.deadLetters.Write(deadletter.NewRecord(entry, Source, err)); err != nil {
+		metrics.ErrorsTotal.Inc()
+		log.Errorf("Error writing dead letter of %s: %v", entry.Link, err)
+	}
+}
+
+// processEntries retrieves the information of the flights of a page with a pool of workers.
+//
+// The flights are returned in the order of the entries, without the existing ones.
+// The flights whose page cannot be parsed are sent to the dead letters,
+// the other failures are returned to be retried later.
+func (e *Extractor) processEntries(ctx context.Context, entries []parser.Entry) ([]*parser.Flight, []store.FailedFlight) {
+	parallelism := e.config.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	results := make([]*parser.Flight, len(entries))
+	errs := make([]error, len(entries))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indices {
+				flight, err := e.processEntry(ctx, entries[index])
+				if err != nil {
+					metrics.ErrorsTotal.Inc()
+					log.Errorf("Error getting flight information of %s: %v", entries[index].Link, err)
+					errs[index] = err
+					continue
+				}
+				results[index] = flight
+			}
+		}()
+	}
+	for index := range entries {
+		if ctx.Err() != nil {
+			break
+		}
+		indices <- index
+	}
+	close(indices)
+	wg.Wait()
+
+	var (
+		flights []*parser.Flight
+		failed  []store.FailedFlight
+	)
+	for i, flight := range results {
+		var parseError *parser.ParseError
+		switch {
+		case errors.As(errs[i], &parseError):
+			// Parsing again the same page would fail the same way.
+			e.writeDeadLetter(entries[i], errs[i])
+		case errs[i] != nil:
+			failed = append(failed, store.FailedFlight{Entry: entries[i], Error: errs[i].Error(), Attempts: 1})
+		case flight != nil:
+			flights = append(flights, flight)
+		}
+	}
+	return flights, failed
+}
+
+// insertFlights stores the flights in a single batch.
+//
+// The flights which failed to be stored are returned to be retried later with their entry, which has the
+// fields only displayed in the table, e.g. the time zone of the take-off.
+// The error is only set if the whole batch failed.
+func (e *Extractor) insertFlights(flights []*parser.Flight, entries []parser.Entry) ([]store.FailedFlight, error) {
+	result, err := e.manager.InsertFlights(flights)
+	if err != nil {
+		metrics.ErrorsTotal.Inc()
+		return nil, fmt.Errorf("error indexing flights into the store: %w", err)
+	}
+	log.Debugf("Inserted %d flights successfully.", len(result.Inserted))
+	metrics.DocumentsTotal.Add(float64(len(result.Inserted)))
+	metrics.DuplicatesTotal.Add(float64(len(result.Duplicates)))
+	if err = e.manager.UpdatePilots(result.Inserted); err != nil {
+		metrics.ErrorsTotal.Inc()
+		log.Errorf("Error updating the pilots: %v", err)
+	}
+
+	// The url of a flight is the link of its entry.
+	entriesByLink := make(map[string]parser.Entry, len(entries))
+	for _, entry := range entries {
+		entriesByLink[entry.Link] = entry
+	}
+	var failed []store.FailedFlight
+	for _, item := range result.Failed {
+		metrics.ErrorsTotal.Inc()
+		metrics.BulkFailedItemsTotal.Inc()
+		failed = append(failed, store.FailedFlight{Entry: entriesByLink[item.Flight.Url], Error: item.Err.Error(), Attempts: 1})
+	}
+	return failed, nil
+}
+
+// retryFailedFlights processes again the flights which failed in the previous pages.
+//
+// The flights still failing are kept in the state until they reach the maximal number of attempts,
+// they are then sent to the dead letters.
+func (e *Extractor) retryFailedFlights(ctx context.Context, state *store.DownloadState) error {
+	log.Infof("Retrying %d failed flights", len(state.FailedFlights))
+	entries := make([]parser.Entry, len(state.FailedFlights))
+	attempts := make(map[string]int, len(state.FailedFlights))
+	for i, failedFlight := range state.FailedFlights {
+		entries[i] = failedFlight.Entry
+		attempts[failedFlight.Entry.Link] = failedFlight.Attempts
+	}
+	flights, failed := e.processEntries(ctx, entries)
+	if ctx.Err() != nil {
+		return nil
+	}
+	failedInserts, err := e.insertFlights(flights, entries)
+	if err != nil {
+		return err
+	}
+	failed = append(failed, failedInserts...)
+
+	var remaining []store.FailedFlight
+	for _, failedFlight := range failed {
+		failedFlight.Attempts = attempts[failedFlight.Entry.Link] + 1
+		if failedFlight.Attempts >= e.config.MaxAttempts {
+			log.Errorf("Giving up flight %s after %d attempts: %s", failedFlight.Entry.Link, failedFlight.Attempts, failedFlight.Error)
+			e.writeDeadLetter(failedFlight.Entry, errors.New(failedFlight.Error))
+			continue
+		}
+		remaining = append(remaining, failedFlight)
+	}
+	log.Infof("%d flights recovered, %d still failing", len(state.FailedFlights)-len(failed), len(remaining))
+	state.FailedFlights = remaining
+	return nil
+}
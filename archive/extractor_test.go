@@ -0,0 +1,175 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"fahy.xyz/xcontestextractor/boltdb"
+	"fahy.xyz/xcontestextractor/deadletter"
+	"fahy.xyz/xcontestextractor/metrics"
+	"fahy.xyz/xcontestextractor/pagesource"
+	"fahy.xyz/xcontestextractor/parser"
+	"fahy.xyz/xcontestextractor/store"
+)
+
+func TestMain(m *testing.M) {
+	metrics.InitPrometheus(metrics.Config{Namespace: "test", Subsystem: "archive", Path: "/metrics"}, http.NewServeMux())
+	os.Exit(m.Run())
+}
+
+// fakePages answers the pages of the archive by start parameter, the missing pages having no flight.
+type fakePages struct {
+	pages    map[int]string
+	errs     map[int]error
+	requests []int
+}
+
+func (p *fakePages) GetPage(_ context.Context, url string) (string, error) {
+	start, _ := strconv.Atoi(url[strings.LastIndex(url, "=")+1:])
+	p.requests = append(p.requests, start)
+	return p.pages[start], p.errs[start]
+}
+
+func (p *fakePages) Close() error {
+	return nil
+}
+
+func newTestExtractor(t *testing.T, pages pagesource.Source) (*Extractor, *boltdb.BoltManager, string) {
+	t.Helper()
+	dir := t.TempDir()
+	manager, err := boltdb.NewBoltManager(filepath.Join(dir, "flights.db"), "flight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { manager.Close() })
+	deadLetterPath := filepath.Join(dir, "dead-letters.jsonl")
+	deadLetters, err := deadletter.NewWriter(deadLetterPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extractor := NewExtractor(manager, parser.NewFetcher(nil, nil, nil), pages, deadLetters, Config{
+		Parallelism:     1,
+		MaxAttempts:     3,
+		NumberOfRetries: 2,
+	})
+	return extractor, manager, deadLetterPath
+}
+
+func TestExtractFetchError(t *testing.T) {
+	pages := &fakePages{errs: map[int]error{100: errors.New("unexpected status code 429")}}
+	extractor, manager, _ := newTestExtractor(t, pages)
+
+	state := &store.DownloadState{Year: 2021, LastFlightNumber: 100}
+	if err := extractor.Extract(context.Background(), "https://www.xcontest.org/world/en/flights/#flights[start]=", state); err == nil {
+		t.Fatal("Expected the error of the page")
+	}
+	// A page which cannot be downloaded is not the end of the archive.
+	saved, err := manager.GetDownloadState(2021, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The page is retried before giving up.
+	if state.Complete || saved.Complete || !reflect.DeepEqual(pages.requests, []int{100, 100, 100}) {
+		t.Errorf("Wrong state after a failed page: %+v, requests %v", saved, pages.requests)
+	}
+
+	// A page failing once is processed normally, up to the end of the archive.
+	extractor, _, _ = newTestExtractor(t, &flakyPages{fakePages: &fakePages{}, failures: 1})
+	state = &store.DownloadState{Year: 2021, LastFlightNumber: 100}
+	if err = extractor.Extract(context.Background(), "https://www.xcontest.org/world/en/flights/#flights[start]=", state); err != nil {
+		t.Fatalf("Error extracting after a failed page: %v", err)
+	}
+	if !state.Complete {
+		t.Errorf("The archive is not complete after a page failing once: %+v", state)
+	}
+}
+
+// flakyPages fails the first requests of the pages before answering them.
+type flakyPages struct {
+	*fakePages
+	failures int
+}
+
+func (p *flakyPages) GetPage(ctx context.Context, url string) (string, error) {
+	if p.failures > 0 {
+		p.failures--
+		p.requests = append(p.requests, -1)
+		return "", errors.New("timeout")
+	}
+	return p.fakePages.GetPage(ctx, url)
+}
+
+func TestExtractEnd(t *testing.T) {
+	var flightRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flightRequests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	pages := &fakePages{}
+	extractor, manager, deadLetterPath := newTestExtractor(t, pages)
+	entry := parser.Entry{FullName: "Pilot", Link: server.URL + "/flights/detail:pilot/1.5.2021/10:00"}
+	state := &store.DownloadState{Year: 2021, FailedFlights: []store.FailedFlight{{Entry: entry, Error: "timeout", Attempts: 1}}}
+	if err := extractor.Extract(context.Background(), "https://www.xcontest.org/world/en/flights/#flights[start]=", state); err != nil {
+		t.Fatalf("Error extracting: %v", err)
+	}
+	if len(pages.requests) != 3 {
+		t.Errorf("Wrong number of empty pages requested: %v", pages.requests)
+	}
+	// The failed flight is retried once per interval until its maximal number of attempts.
+	if flightRequests != 2 {
+		t.Errorf("Wrong number of retries: %d", flightRequests)
+	}
+	saved, err := manager.GetDownloadState(2021, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !saved.Complete || len(saved.FailedFlights) != 0 {
+		t.Errorf("Wrong state at the end of the archive: %+v", saved)
+	}
+	records, err := deadletter.ReadRecords(deadLetterPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Entry.Link != entry.Link {
+		t.Errorf("Wrong dead letters: %+v", records)
+	}
+}
+
+// failingStore fails to store all the flights.
+type failingStore struct {
+	*boltdb.BoltManager
+}
+
+func (s failingStore) InsertFlights(flights []*parser.Flight) (store.InsertResult, error) {
+	var result store.InsertResult
+	for _, flight := range flights {
+		result.Failed = append(result.Failed, store.ItemError{Flight: flight, Err: errors.New("rejected")})
+	}
+	return result, nil
+}
+
+func TestInsertFlightsKeepsEntries(t *testing.T) {
+	extractor, manager, _ := newTestExtractor(t, &fakePages{})
+	extractor.manager = failingStore{manager}
+	entry := parser.Entry{FullName: "Pilot", Link: "https://www.xcontest.org/world/en/flights/detail:pilot/1.5.2021/10:00", UtcOffset: "+02:00"}
+	flight := &parser.Flight{FullName: entry.FullName, Url: entry.Link}
+
+	failed, err := extractor.insertFlights([]*parser.Flight{flight}, []parser.Entry{entry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The entry is retried with the fields of the table, e.g. the time zone.
+	if len(failed) != 1 || failed[0].Entry != entry {
+		t.Errorf("Wrong failed flights: %+v", failed)
+	}
+}
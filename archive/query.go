@@ -0,0 +1,93 @@
+package archive
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// BaseUrl is the url of XContest.
+	BaseUrl string = "https://www.xcontest.org"
+	// DefaultLeague is the league with the flights of the whole world.
+	DefaultLeague string = "world"
+	// dateLayout is the format of the dates of the filters of the archive.
+	dateLayout string = "2006-01-02"
+)
+
+// Query selects the flights of a season of the archive.
+type Query struct {
+	// Season of the archive, e.g. 2022 for the flights from October 2021 to September 2022.
+	Season int
+	// First and last dates of the flights, both included.
+	From time.Time
+	To   time.Time
+	// League of the archive, e.g. `world` or `switzerland`.
+	League string
+	// Country of the take-off, e.g. `CH`, all the countries if empty.
+	Country string
+}
+
+// Season returns the season of the archive of a date.
+//
+// The seasons of XContest start on the 1st of October of the previous year.
+func Season(date time.Time) int {
+	if date.Month() >= time.October {
+		return date.Year() + 1
+	}
+	return date.Year()
+}
+
+// SplitBySeason splits the dates from `from` to `to`, both included, into one query per season.
+func SplitBySeason(from time.Time, to time.Time, league string, country string) ([]Query, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("end date %s is before start date %s", to.Format(dateLayout), from.Format(dateLayout))
+	}
+	if league == "" {
+		league = DefaultLeague
+	}
+	var queries []Query
+	for start := from; !start.After(to); {
+		season := Season(start)
+		// Last day of the season.
+		end := time.Date(season, time.September, 30, 0, 0, 0, 0, start.Location())
+		if end.After(to) {
+			end = to
+		}
+		queries = append(queries, Query{
+			Season:  season,
+			From:    start,
+			To:      end,
+			League:  league,
+			Country: strings.ToUpper(country),
+		})
+		start = end.AddDate(0, 0, 1)
+	}
+	return queries, nil
+}
+
+// Url returns the url of the pages of the flights of the query, ending with the start parameter of the page,
+// e.g. `https://www.xcontest.org/2022/world/en/flights/?filter[date_mode]=period&...#flights[start]=`.
+func (q Query) Url() string {
+	// The parameters are not escaped to keep the url readable, as for the urls of the archive.
+	params := []string{
+		"filter[date_mode]=period",
+		"filter[date]=" + q.From.Format(dateLayout),
+		"filter[date_to]=" + q.To.Format(dateLayout),
+	}
+	if q.Country != "" {
+		params = append(params, "filter[country]="+url.QueryEscape(q.Country))
+	}
+	return fmt.Sprintf("%s/%d/%s/en/flights/?%s#flights[start]=", BaseUrl, q.Season, q.League, strings.Join(params, "&"))
+}
+
+// Scope returns the scope of the download state of the query, e.g. `world-CH-2021-10-01-2022-09-30`.
+func (q Query) Scope() string {
+	parts := []string{q.League}
+	if q.Country != "" {
+		parts = append(parts, q.Country)
+	}
+	parts = append(parts, q.From.Format(dateLayout), q.To.Format(dateLayout))
+	return strings.Join(parts, "-")
+}
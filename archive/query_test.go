@@ -0,0 +1,55 @@
+package archive
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestSeason(t *testing.T) {
+	if season := Season(date(2021, time.September, 30)); season != 2021 {
+		t.Errorf("Wrong season: %d", season)
+	}
+	if season := Season(date(2021, time.October, 1)); season != 2022 {
+		t.Errorf("Wrong season: %d", season)
+	}
+}
+
+func TestSplitBySeason(t *testing.T) {
+	queries, err := SplitBySeason(date(2021, time.May, 15), date(2022, time.November, 2), "", "ch")
+	if err != nil {
+		t.Fatalf("Error splitting the dates: %v", err)
+	}
+	expected := []Query{
+		{Season: 2021, From: date(2021, time.May, 15), To: date(2021, time.September, 30), League: "world", Country: "CH"},
+		{Season: 2022, From: date(2021, time.October, 1), To: date(2022, time.September, 30), League: "world", Country: "CH"},
+		{Season: 2023, From: date(2022, time.October, 1), To: date(2022, time.November, 2), League: "world", Country: "CH"},
+	}
+	if len(queries) != len(expected) {
+		t.Fatalf("Expected %d queries, got %d: %+v", len(expected), len(queries), queries)
+	}
+	for i := range expected {
+		if queries[i] != expected[i] {
+			t.Errorf("Query %d is wrong: %+v", i, queries[i])
+		}
+	}
+
+	if _, err = SplitBySeason(date(2022, time.May, 1), date(2022, time.April, 30), "", ""); err == nil {
+		t.Error("Expected an error when the end date is before the start date")
+	}
+}
+
+func TestQueryUrl(t *testing.T) {
+	query := Query{Season: 2022, From: date(2022, time.May, 1), To: date(2022, time.May, 31), League: "world", Country: "CH"}
+	expected := "https://www.xcontest.org/2022/world/en/flights/?filter[date_mode]=period&filter[date]=2022-05-01" +
+		"&filter[date_to]=2022-05-31&filter[country]=CH#flights[start]="
+	if url := query.Url(); url != expected {
+		t.Errorf("Wrong url: %s", url)
+	}
+	if scope := query.Scope(); scope != "world-CH-2022-05-01-2022-05-31" {
+		t.Errorf("Wrong scope: %s", scope)
+	}
+}
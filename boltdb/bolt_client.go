@@ -0,0 +1,203 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fahy.xyz/xcontestextractor/parser"
+	"fahy.xyz/xcontestextractor/store"
+	"github.com/sqooba/go-common/logging"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	log = logging.NewLogger()
+)
+
+const (
+	stateBucketName = "download-state"
+	pilotBucketName = "pilot"
+	openTimeout     = 10 * time.Second
+)
+
+// BoltManager stores the flights into an embedded bbolt database.
+//
+// It is meant for small deployments where running ElasticSearch is not worth it.
+type BoltManager struct {
+	db         *bolt.DB
+	bucketName string
+}
+
+// NewBoltManager creates a new instance of the BoltManager.
+//
+// The database file is created if it does not exist yet.
+func NewBoltManager(path string, bucketName string) (*BoltManager, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range []string{bucketName, stateBucketName, pilotBucketName} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltManager{db: db, bucketName: bucketName}, nil
+}
+
+// FlightExists check if a flight already exist.
+//
+// The flight is looked up by its key, extracted from the url of the flight.
+func (manager *BoltManager) FlightExists(url string) (bool, error) {
+	key, err := parser.ExtractFlightKey(url)
+	if err != nil {
+		return false, err
+	}
+	exists := false
+	err = manager.db.View(func(tx *bolt.Tx) error {
+		exists = tx.Bucket([]byte(manager.bucketName)).Get([]byte(key)) != nil
+		return nil
+	})
+	return exists, err
+}
+
+// putFlight stores a flight in the bucket if its key is not used yet.
+func putFlight(bucket *bolt.Bucket, flight *parser.Flight) error {
+	key, err := parser.ExtractFlightKey(flight.Url)
+	if err != nil {
+		return err
+	}
+	if bucket.Get([]byte(key)) != nil {
+		return store.ErrFlightExists
+	}
+	value, err := json.Marshal(flight)
+	if err != nil {
+		return err
+	}
+	log.Debugf("Inserting flight with key: %s", key)
+	return bucket.Put([]byte(key), value)
+}
+
+// InsertFlight insert a single flight.
+func (manager *BoltManager) InsertFlight(flight *parser.Flight) error {
+	return manager.db.Update(func(tx *bolt.Tx) error {
+		return putFlight(tx.Bucket([]byte(manager.bucketName)), flight)
+	})
+}
+
+// InsertFlights insert a batch of flights in a single transaction.
+func (manager *BoltManager) InsertFlights(flights []*parser.Flight) (store.InsertResult, error) {
+	var result store.InsertResult
+	err := manager.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(manager.bucketName))
+		for _, flight := range flights {
+			err := putFlight(bucket, flight)
+			if errors.Is(err, store.ErrFlightExists) {
+				result.Duplicates = append(result.Duplicates, flight)
+				continue
+			}
+			if err != nil {
+				log.Errorf("Error inserting flight %s: %v", flight.Url, err)
+				result.Failed = append(result.Failed, store.ItemError{Flight: flight, Err: err})
+				continue
+			}
+			result.Inserted = append(result.Inserted, flight)
+		}
+		return nil
+	})
+	if err != nil {
+		return store.InsertResult{}, err
+	}
+	return result, nil
+}
+
+// UpdatePilots adds newly inserted flights to the statistics of their pilots in a single transaction.
+//
+// The flights without pilot handle are skipped and reported in the error.
+func (manager *BoltManager) UpdatePilots(flights []*parser.Flight) error {
+	missing := 0
+	err := manager.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(pilotBucketName))
+		for _, flight := range flights {
+			if flight.PilotHandle == "" {
+				log.Errorf("Error updating the pilot of flight %s: %v", flight.Url, store.ErrNoPilotHandle)
+				missing++
+				continue
+			}
+			pilot := store.NewPilot(flight)
+			if value := bucket.Get([]byte(flight.PilotHandle)); value != nil {
+				if err := json.Unmarshal(value, pilot); err != nil {
+					return err
+				}
+			}
+			pilot.AddFlight(flight)
+			value, err := json.Marshal(pilot)
+			if err != nil {
+				return err
+			}
+			if err = bucket.Put([]byte(flight.PilotHandle), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d pilots failed to be updated: %w", missing, store.ErrNoPilotHandle)
+	}
+	return nil
+}
+
+// GetDownloadState retrieve the checkpoint of the given year and scope.
+func (manager *BoltManager) GetDownloadState(year int, scope string) (*store.DownloadState, error) {
+	state := &store.DownloadState{Year: year, Scope: scope}
+	found := false
+	err := manager.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket([]byte(stateBucketName)).Get([]byte(state.Key()))
+		if value == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(value, state)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		log.Warningf("Unable to get last flight number, set to 0.")
+		return state, nil
+	}
+	log.Debugf("Extracted flight number: %d", state.LastFlightNumber)
+	return state, nil
+}
+
+// SetDownloadState save the checkpoint of a year and scope.
+func (manager *BoltManager) SetDownloadState(state *store.DownloadState) error {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return manager.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(stateBucketName)).Put([]byte(state.Key()), value)
+	})
+}
+
+// Close closes the database file.
+func (manager *BoltManager) Close() error {
+	return manager.db.Close()
+}
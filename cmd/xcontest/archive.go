@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"syscall"
+	"time"
+
+	"fahy.xyz/xcontestextractor/archive"
+	"fahy.xyz/xcontestextractor/pagesource"
+	"fahy.xyz/xcontestextractor/parser"
+	"fahy.xyz/xcontestextractor/store"
+	browser "github.com/EDDYCJY/fake-useragent"
+)
+
+const (
+	// Format of the dates of the backfill.
+	dateLayout string = "2006-01-02"
+)
+
+// archiveConfig is the configuration shared by the extractions of the archive.
+type archiveConfig struct {
+	logConfig
+	extractionConfig
+	// Source of the pages of the archive (browser or http)
+	PageSource  string `envconfig:"PAGE_SOURCE" default:"browser"`
+	BrowserPath string `envconfig:"BROWSER_PATH" default:"/headless-shell/headless-shell"`
+	// Elements waited for in the pages of the archive and of the flights, and number of pages before restarting the browser
+	BrowserWaitSelector       string `envconfig:"BROWSER_WAIT_SELECTOR" default:"table.XClist"`
+	BrowserDetailWaitSelector string `envconfig:"BROWSER_DETAIL_WAIT_SELECTOR" default:"table.XCinfo"`
+	BrowserMaxPages           int    `envconfig:"BROWSER_MAX_PAGES" default:"50"`
+	// Timeouts and number of retries of the pages
+	TimeoutSeconds  int `envconfig:"TIMEOUT_SECONDS" default:"60"`
+	NumberOfRetries int `envconfig:"NUMBER_OF_RETRIES" default:"5"`
+	// Number of flights of a page processed concurrently
+	Parallelism int `envconfig:"PARALLELISM" default:"4"`
+	// Number of attempts before giving up a flight which failed
+	MaxFlightAttempts int `envconfig:"MAX_FLIGHT_ATTEMPTS" default:"3"`
+	// Interval between run to avoid doing too many requests
+	IntervalMin int `envconfig:"RUN_INTERVAL_MINUTES" default:"2"`
+}
+
+type archiveExtractorConfig struct {
+	archiveConfig
+	// URL to extract
+	Url string `envconfig:"URL"`
+	// Start of the extraction (part of the url [start]=)
+	StartFlightNumber    int  `envconfig:"START_FLIGHT_NUMBER"` // Only used if `LOAD_LAST_FLIGHT_NUMBER` is false.
+	LoadLastFlightNumber bool `envconfig:"LOAD_LAST_FLIGHT_NUMBER" default:"true"`
+}
+
+type backfillConfig struct {
+	archiveConfig
+	// Dates of the flights and filters of the archive
+	From    string `envconfig:"BACKFILL_FROM" flag:"from" desc:"First date of the flights to extract, e.g. 2022-05-01."`
+	To      string `envconfig:"BACKFILL_TO" flag:"to" desc:"Last date of the flights to extract, today if empty."`
+	League  string `envconfig:"BACKFILL_LEAGUE" flag:"league" default:"world" desc:"League of the archive, e.g. world or switzerland."`
+	Country string `envconfig:"BACKFILL_COUNTRY" flag:"country" desc:"Country code of the take-offs, e.g. CH, all the countries if empty."`
+	Restart bool   `envconfig:"BACKFILL_RESTART" flag:"restart" desc:"Ignore the saved progress and extract the flights again from the first page."`
+}
+
+// newArchiveExtractor creates the extractor of the pages of the archive and its page source, which must be closed.
+func newArchiveExtractor(env archiveConfig, manager store.FlightStore) (*archive.Extractor, pagesource.Source, error) {
+	log.Infof("Page source           : %s", env.PageSource)
+	policy := newPolicy(env.crawlConfig)
+	userAgent := browser.Random()
+	client := &http.Client{Timeout: time.Duration(env.TimeoutSeconds) * time.Second}
+	fetcher, err := newFetcher(env.crawlConfig, client, userAgent, policy)
+	if err != nil {
+		return nil, nil, err
+	}
+	deadLetters, err := newDeadLetterWriter(env.extractionConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	pages, err := pagesource.New(pagesource.Config{
+		Mode:               env.PageSource,
+		Timeout:            time.Duration(env.TimeoutSeconds) * time.Second,
+		UserAgent:          userAgent,
+		BrowserPath:        env.BrowserPath,
+		WaitSelector:       env.BrowserWaitSelector,
+		DetailWaitSelector: env.BrowserDetailWaitSelector,
+		MaxBrowserPages:    env.BrowserMaxPages,
+	}, policy)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating the page source: %w", err)
+	}
+	// The browser also renders the pages of the flights, to extract their details.
+	if renderer, ok := pages.(parser.Renderer); ok {
+		fetcher.Renderer = renderer
+	}
+	tracks, err := newTrackDownloader(env.extractionConfig, fetcher)
+	if err != nil {
+		pages.Close()
+		return nil, nil, err
+	}
+	extractor := archive.NewExtractor(manager, fetcher, pages, deadLetters, archive.Config{
+		Parallelism:     env.Parallelism,
+		MaxAttempts:     env.MaxFlightAttempts,
+		NumberOfRetries: env.NumberOfRetries,
+		Interval:        time.Duration(env.IntervalMin) * time.Minute,
+	})
+	extractor.Tracks = tracks
+	return extractor, pages, nil
+}
+
+// runArchive extracts the flights of the archive from the url until there is no more flight.
+func runArchive(args []string) error {
+	var env archiveExtractorConfig
+	if _, err := loadConfig("archive", &env, &env.logConfig, args); err != nil {
+		return err
+	}
+
+	startMetrics(env.metricsConfig, "archextractor")
+
+	// Initialization of the storage backend.
+	manager, err := newFlightStore(env.storeConfig)
+	if err != nil {
+		return err
+	}
+	defer manager.Close()
+
+	// Extract year from url.
+	re := regexp.MustCompile(`[0-9]{4}`)
+	match := re.FindString(env.Url)
+	year, err := strconv.Atoi(match)
+	if err != nil {
+		return fmt.Errorf("error extracting the year from the url: %w", err)
+	}
+	log.Infof("Processing year %d", year)
+
+	// Extract the checkpoint of the year.
+	state, err := manager.GetDownloadState(year, "")
+	if err != nil {
+		return fmt.Errorf("error extracting the download state: %w", err)
+	}
+	if !env.LoadLastFlightNumber {
+		state.LastFlightNumber = env.StartFlightNumber
+	}
+	log.Infof("Last flight number: %d", state.LastFlightNumber)
+	log.Infof("Failed flights to retry: %d", len(state.FailedFlights))
+
+	// Cancel the requests in progress when receiving a shutdown signal.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	extractor, pages, err := newArchiveExtractor(env.archiveConfig, manager)
+	if err != nil {
+		return err
+	}
+	defer pages.Close()
+
+	// Process all the pages until there is no more flight.
+	if err = extractor.Extract(ctx, env.Url, state); err != nil {
+		if ctx.Err() != nil {
+			log.Info("Shutdown signal received, exiting...")
+			return nil
+		}
+		return fmt.Errorf("error extracting the archive: %w", err)
+	}
+
+	log.Info("Flights successfully imported.")
+	time.Sleep(30 * time.Second)
+	return nil
+}
+
+// runBackfill extracts the flights of the archive between two dates, season by season.
+func runBackfill(args []string) error {
+	var env backfillConfig
+	if _, err := loadConfig("backfill", &env, &env.logConfig, args); err != nil {
+		return err
+	}
+
+	from, err := time.Parse(dateLayout, env.From)
+	if err != nil {
+		return fmt.Errorf("invalid start date %q: %w", env.From, err)
+	}
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if env.To != "" {
+		if to, err = time.Parse(dateLayout, env.To); err != nil {
+			return fmt.Errorf("invalid end date %q: %w", env.To, err)
+		}
+	}
+	queries, err := archive.SplitBySeason(from, to, env.League, env.Country)
+	if err != nil {
+		return err
+	}
+
+	startMetrics(env.metricsConfig, "backfill")
+
+	// Initialization of the storage backend.
+	manager, err := newFlightStore(env.storeConfig)
+	if err != nil {
+		return err
+	}
+	defer manager.Close()
+
+	// Cancel the requests in progress when receiving a shutdown signal.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	extractor, pages, err := newArchiveExtractor(env.archiveConfig, manager)
+	if err != nil {
+		return err
+	}
+	defer pages.Close()
+
+	for _, query := range queries {
+		// Extract the checkpoint of the season, the seasons already extracted are skipped.
+		state, err := manager.GetDownloadState(query.Season, query.Scope())
+		if err != nil {
+			return fmt.Errorf("error extracting the download state: %w", err)
+		}
+		if env.Restart {
+			state = &store.DownloadState{Year: query.Season, Scope: query.Scope()}
+		}
+		if state.Complete {
+			log.Infof("Season %d already extracted (%s), skipping.", query.Season, query.Scope())
+			continue
+		}
+		log.Infof("Processing season %d from %s to %s", query.Season, query.From.Format(dateLayout), query.To.Format(dateLayout))
+		log.Infof("Last flight number: %d", state.LastFlightNumber)
+		log.Infof("Failed flights to retry: %d", len(state.FailedFlights))
+
+		if err = extractor.Extract(ctx, query.Url(), state); err != nil {
+			if ctx.Err() != nil {
+				log.Info("Shutdown signal received, exiting...")
+				return nil
+			}
+			return fmt.Errorf("error extracting the season %d: %w", query.Season, err)
+		}
+	}
+
+	log.Info("Flights successfully imported.")
+	return nil
+}
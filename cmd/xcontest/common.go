@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"fahy.xyz/xcontestextractor/boltdb"
+	"fahy.xyz/xcontestextractor/crawl"
+	"fahy.xyz/xcontestextractor/deadletter"
+	"fahy.xyz/xcontestextractor/elastic"
+	"fahy.xyz/xcontestextractor/igc"
+	"fahy.xyz/xcontestextractor/metrics"
+	"fahy.xyz/xcontestextractor/parser"
+	"fahy.xyz/xcontestextractor/store"
+	browser "github.com/EDDYCJY/fake-useragent"
+	"github.com/sqooba/go-common/logging"
+	"github.com/sqooba/go-common/version"
+)
+
+const (
+	// Index to store the entries.
+	indexName string = "flight"
+)
+
+var (
+	log = logging.NewLogger()
+)
+
+type logConfig struct {
+	// Logging
+	LogLevel string `envconfig:"LOG_LEVEL" default:"info"`
+}
+
+type elasticConfig struct {
+	// ElasticSearch
+	ElasticEndpoint string `envconfig:"ELASTICSEARCH_URL" default:"http://127.0.0.1:9200"`
+	ElasticUser     string `envconfig:"ELASTICSEARCH_USERNAME" default:"CHANGEME"`
+	ElasticPassword string `envconfig:"ELASTICSEARCH_PASSWORD" default:"CHANGEME"`
+	// Bulk indexing
+	BulkFlushBytes    int           `envconfig:"BULK_FLUSH_BYTES" default:"5000000"`
+	BulkFlushInterval time.Duration `envconfig:"BULK_FLUSH_INTERVAL" default:"30s"`
+	// Size of the primary shard of the flights index triggering a rollover, the unit is mandatory
+	MaxIndexSize string `envconfig:"MAX_INDEX_SIZE" default:"10GB"`
+	// Backing indices of the flights (none, yearly or monthly)
+	ElasticPartition string `envconfig:"ELASTICSEARCH_PARTITION" default:"none"`
+}
+
+type storeConfig struct {
+	elasticConfig
+	// Storage backend (elastic or bolt)
+	StoreBackend string `envconfig:"STORE_BACKEND" default:"elastic"`
+	BoltPath     string `envconfig:"BOLT_PATH" default:"./data/xcontest.db"`
+	// Set up the templates and the indices at startup, the mapping of the flights is checked in any case
+	ElasticSetup bool `envconfig:"ELASTICSEARCH_SETUP" default:"true"`
+}
+
+type crawlConfig struct {
+	// Crawling policy
+	CrawlRequestsPerSecond float64       `envconfig:"CRAWL_REQUESTS_PER_SECOND" default:"1"`
+	CrawlBurst             int           `envconfig:"CRAWL_BURST" default:"5"`
+	CrawlJitter            time.Duration `envconfig:"CRAWL_JITTER" default:"500ms"`
+	CrawlMaxRetries        int           `envconfig:"CRAWL_MAX_RETRIES" default:"3"`
+	CrawlInitialBackoff    time.Duration `envconfig:"CRAWL_INITIAL_BACKOFF" default:"5s"`
+	CrawlMaxBackoff        time.Duration `envconfig:"CRAWL_MAX_BACKOFF" default:"10m"`
+	// CSV file with the location of the take-offs, optional
+	GazetteerPath string `envconfig:"GAZETTEER_PATH"`
+}
+
+type metricsConfig struct {
+	// Prometheus, the subsystem defaults to the name of the command
+	MetricsNamespace string `envconfig:"METRICS_NAMESPACE" default:"xcontest"`
+	MetricsSubsystem string `envconfig:"METRICS_SUBSYSTEM"`
+	MetricsPath      string `envconfig:"METRICS_PATH" default:"/metrics"`
+	Port             string `envconfig:"PORT" default:"9095"`
+}
+
+type extractionConfig struct {
+	storeConfig
+	crawlConfig
+	metricsConfig
+	// Download of the IGC tracks
+	IgcDownload    bool   `envconfig:"IGC_DOWNLOAD" default:"false"`
+	IgcPath        string `envconfig:"IGC_PATH" default:"./data/igc"`
+	IgcUrlTemplate string `envconfig:"IGC_URL_TEMPLATE"`
+	// File where the flights which cannot be extracted are saved
+	DeadLetterPath string `envconfig:"DEADLETTER_PATH" default:"./data/deadletter.jsonl"`
+}
+
+// logVersion logs the name of the command and the version of the binary.
+func logVersion(name string) {
+	log.Infof("Starting XContest %s...", name)
+	log.Infof("Version               : %s", version.Version)
+	log.Infof("Commit                : %s", version.GitCommit)
+	log.Infof("Build date            : %s", version.BuildDate)
+	log.Infof("OSarch                : %s", version.OsArch)
+}
+
+// setLogLevel sets the level of the logs of the command.
+func setLogLevel(config logConfig) error {
+	if err := logging.SetLogLevel(log, config.LogLevel); err != nil {
+		return fmt.Errorf("logging level %s do not seem to be right: %w", config.LogLevel, err)
+	}
+	return nil
+}
+
+// startMetrics starts the prometheus server in the background.
+func startMetrics(config metricsConfig, subsystem string) {
+	if config.MetricsSubsystem != "" {
+		subsystem = config.MetricsSubsystem
+	}
+	mConfig := metrics.Config{
+		Namespace: config.MetricsNamespace,
+		Subsystem: subsystem,
+		Path:      config.MetricsPath,
+	}
+	metrics.InitPrometheus(mConfig, http.DefaultServeMux)
+	s := http.Server{Addr: fmt.Sprint(":", config.Port)}
+	go func() {
+		log.Fatal(s.ListenAndServe())
+	}()
+}
+
+// newElasticManager creates the manager of ElasticSearch.
+func newElasticManager(config elasticConfig) (*elastic.ElasticManager, error) {
+	partitioning, err := elastic.ParsePartitioning(config.ElasticPartition)
+	if err != nil {
+		return nil, err
+	}
+	return elastic.NewElasticManager(
+		config.ElasticEndpoint,
+		config.ElasticUser,
+		config.ElasticPassword,
+		indexName,
+		elastic.BulkConfig{
+			FlushBytes:    config.BulkFlushBytes,
+			FlushInterval: config.BulkFlushInterval,
+		},
+		partitioning,
+	)
+}
+
+// newFlightStore creates the storage backend selected in the configuration.
+func newFlightStore(config storeConfig) (store.FlightStore, error) {
+	log.Infof("Store backend         : %s", config.StoreBackend)
+	switch config.StoreBackend {
+	case store.BackendElastic:
+		log.Infof("Elastic endpoint      : %s", config.ElasticEndpoint)
+		log.Infof("Elastic user          : %s", config.ElasticUser)
+		log.Infof("Elastic partition     : %s", config.ElasticPartition)
+		manager, err := newElasticManager(config.elasticConfig)
+		if err != nil {
+			return nil, err
+		}
+		// Fail fast instead of indexing flights with a wrong mapping or into an index to migrate.
+		if config.ElasticSetup {
+			err = manager.EnsureIndices(context.Background(), config.MaxIndexSize)
+		} else if err = manager.CheckSchemaVersion(context.Background()); err == nil {
+			err = manager.CheckMapping(context.Background())
+		}
+		if err != nil {
+			manager.Close()
+			return nil, migrateHint(err)
+		}
+		return manager, nil
+	case store.BackendBolt:
+		manager, err := boltdb.NewBoltManager(config.BoltPath, indexName)
+		if err != nil {
+			return nil, err
+		}
+		return manager, nil
+	}
+	return nil, fmt.Errorf("unknown store backend: %s", config.StoreBackend)
+}
+
+// newPolicy creates the crawling policy shared by all the requests to XContest.
+func newPolicy(config crawlConfig) *crawl.Policy {
+	return crawl.NewPolicy(crawl.Config{
+		RequestsPerSecond: config.CrawlRequestsPerSecond,
+		Burst:             config.CrawlBurst,
+		Jitter:            config.CrawlJitter,
+		MaxRetries:        config.CrawlMaxRetries,
+		InitialBackoff:    config.CrawlInitialBackoff,
+		MaxBackoff:        config.CrawlMaxBackoff,
+	})
+}
+
+// newFetcher creates the fetcher of the pages of the flights, with the gazetteer if any.
+func newFetcher(config crawlConfig, client *http.Client, userAgent string, policy *crawl.Policy) (*parser.Fetcher, error) {
+	if userAgent == "" {
+		userAgent = browser.Random()
+	}
+	fetcher := parser.NewFetcher(client, http.Header{"User-Agent": []string{userAgent}}, policy)
+	if config.GazetteerPath != "" {
+		var err error
+		if fetcher.Gazetteer, err = parser.LoadGazetteer(config.GazetteerPath); err != nil {
+			return nil, fmt.Errorf("error loading the gazetteer: %w", err)
+		}
+	}
+	return fetcher, nil
+}
+
+// newTrackDownloader creates the downloader of the IGC tracks, nil if disabled.
+//
+// The url of the tracks is built from the template, unless the pages of the flights rendered by the fetcher contain it.
+func newTrackDownloader(config extractionConfig, fetcher *parser.Fetcher) (*igc.Downloader, error) {
+	if !config.IgcDownload {
+		return nil, nil
+	}
+	if config.IgcUrlTemplate == "" && fetcher.Renderer == nil {
+		return nil, errors.New("IGC_URL_TEMPLATE is required to download the IGC tracks without rendering the pages of the flights")
+	}
+	tracks, err := igc.NewDownloader(fetcher, config.IgcPath, config.IgcUrlTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the IGC downloader: %w", err)
+	}
+	return tracks, nil
+}
+
+// newDeadLetterWriter creates the writer of the flights which cannot be extracted.
+func newDeadLetterWriter(config extractionConfig) (*deadletter.Writer, error) {
+	deadLetters, err := deadletter.NewWriter(config.DeadLetterPath)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the dead letters file: %w", err)
+	}
+	return deadLetters, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"fahy.xyz/xcontestextractor/parser"
+)
+
+const (
+	manualSource string = "manual"
+)
+
+type fetchFlightConfig struct {
+	logConfig
+	crawlConfig
+	Timeout time.Duration `envconfig:"TIMEOUT" default:"60s"`
+	// Flight to extract, it can also be given as argument
+	Url string `envconfig:"FLIGHT_URL" flag:"url" desc:"Url of the flight, e.g. https://www.xcontest.org/world/en/flights/detail:Nicober/7.11.2021/13:13."`
+}
+
+// runFetchFlight extracts the information of a single flight and prints it as JSON.
+//
+// The flight is not stored: its pilot name, distance and type are only known from the RSS feed or the archive.
+func runFetchFlight(args []string) error {
+	var env fetchFlightConfig
+	args, err := loadConfig("fetch-flight", &env, &env.logConfig, args)
+	if err != nil {
+		return err
+	}
+	if env.Url == "" && len(args) > 0 {
+		env.Url = args[0]
+	}
+	if env.Url == "" {
+		return errors.New("missing url of the flight")
+	}
+
+	client := &http.Client{Timeout: env.Timeout}
+	fetcher, err := newFetcher(env.crawlConfig, client, "", newPolicy(env.crawlConfig))
+	if err != nil {
+		return err
+	}
+	flight, err := fetcher.GetFlightInfo(context.Background(), env.Url, manualSource)
+	if err != nil {
+		return fmt.Errorf("error getting flight information: %w", err)
+	}
+	flight.Url = env.Url
+	// The date of the flight is part of its key, e.g. `detail:Nicober/7.11.2021/13:13`.
+	if key, err := parser.ExtractFlightKey(env.Url); err == nil {
+		if parts := strings.Split(key, "/"); len(parts) == 3 {
+			if date, err := parser.ParseDate(parts[1]); err == nil {
+				flight.FlightDate = date.UnixMilli()
+			}
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(flight)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"fahy.xyz/xcontestextractor/config"
+)
+
+// command is a subcommand of the binary.
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+var commands = []command{
+	{name: "rss", description: "Extract the flights of the RSS feed periodically.", run: runRss},
+	{name: "archive", description: "Extract the flights of a year of the archive.", run: runArchive},
+	{name: "backfill", description: "Extract the flights of the archive between two dates.", run: runBackfill},
+	{name: "fetch-flight", description: "Extract a single flight and print it as JSON.", run: runFetchFlight},
+	{name: "replay", description: "Extract again the flights saved as dead letters.", run: runReplay},
+	{name: "reindex", description: "Compute the duration in seconds of the flights indexed before.", run: runReindex},
+	{name: "migrate", description: "Apply the migrations of the flights index, or roll back the last one.", run: runMigrate},
+	{name: "partition", description: "List the partitions of the flights, or delete or merge one of them.", run: runPartition},
+	{name: "serve", description: "Serve the indexed flights, pilots and weekly statistics over HTTP.", run: runServe},
+	{name: "stats", description: "Compute the weekly statistics of the flights by year and country.", run: runStats},
+	{name: "setup-index", description: "Create the templates and the indices of ElasticSearch.", run: runSetupIndex},
+}
+
+// loadConfig loads the configuration of a command and sets the level of the logs.
+//
+// The remaining arguments, after the flags, are returned.
+func loadConfig(name string, spec interface{}, logs *logConfig, args []string) ([]string, error) {
+	args, err := config.Load("xcontest "+name, spec, args)
+	if err != nil {
+		return nil, err
+	}
+	if err = setLogLevel(*logs); err != nil {
+		return nil, err
+	}
+	logVersion(name)
+	return args, nil
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: xcontest <command> [flags]\n\n")
+	fmt.Fprintf(os.Stderr, "The settings are read from the flags, the environment variables and the YAML file\n")
+	fmt.Fprintf(os.Stderr, "set with --%s or %s, in this order.\n\nCommands:\n", config.FileFlag, config.FileEnv)
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", c.name, c.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'xcontest <command> --help' for the flags of a command.\n")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
+	}
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		if err := c.run(os.Args[2:]); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return
+			}
+			log.Fatalf("Error running %s: %v", name, err)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+	usage()
+	os.Exit(2)
+}
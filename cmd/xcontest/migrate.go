@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"fahy.xyz/xcontestextractor/elastic"
+)
+
+type migrateConfig struct {
+	logConfig
+	elasticConfig
+	DryRun   bool `envconfig:"MIGRATE_DRY_RUN" flag:"dry-run" desc:"Only print the migrations, without applying them."`
+	Rollback bool `envconfig:"MIGRATE_ROLLBACK" flag:"rollback" desc:"Move the alias back onto the indices preceding the last migration."`
+}
+
+// runMigrate applies the pending migrations of the flights index, or rolls back the last one.
+func runMigrate(args []string) error {
+	var env migrateConfig
+	if _, err := loadConfig("migrate", &env, &env.logConfig, args); err != nil {
+		return err
+	}
+	log.Infof("Elastic endpoint      : %s", env.ElasticEndpoint)
+
+	manager, err := newElasticManager(env.elasticConfig)
+	if err != nil {
+		return fmt.Errorf("error creating the elastic manager: %w", err)
+	}
+	defer manager.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if env.Rollback {
+		previous, err := manager.Rollback(ctx, env.DryRun)
+		if err != nil {
+			return err
+		}
+		for _, group := range previous.Groups {
+			log.Infof("%s: %v, written into %s", group.Alias, group.Indices, group.WriteIndex)
+		}
+		if env.DryRun {
+			log.Infof("Dry run: the rollback would restore version %d onto the indices above", previous.Version)
+		} else {
+			log.Infof("Version %d restored onto the indices above", previous.Version)
+		}
+		return nil
+	}
+
+	// The new indices are created from the templates.
+	if !env.DryRun {
+		if err = manager.SetupIndices(ctx, env.MaxIndexSize); err != nil {
+			return err
+		}
+	}
+	plan, err := manager.Migrate(ctx, env.DryRun)
+	if err != nil {
+		return err
+	}
+	if len(plan.Pending) == 0 {
+		log.Infof("Schema version %d is up-to-date", plan.From)
+		return nil
+	}
+	for _, migration := range plan.Pending {
+		log.Infof("Migration %d: %s", migration.Version, migration.Description)
+	}
+	for _, group := range plan.Groups {
+		log.Infof("%s: %v, written into %s", group.Alias, group.Indices, group.WriteIndex)
+	}
+	if env.DryRun {
+		log.Infof("Dry run: the indices above would be reindexed from version %d to %d", plan.From, plan.To)
+		return nil
+	}
+	log.Infof("Migrated from version %d to %d, the former indices above can be deleted once the rollback is not needed",
+		plan.From, plan.To)
+	return nil
+}
+
+// migrateHint adds the command to run to the error of a flights index with pending migrations.
+func migrateHint(err error) error {
+	if errors.Is(err, elastic.ErrSchemaBehind) {
+		return fmt.Errorf("%w, run `xcontest migrate`", err)
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type partitionConfig struct {
+	logConfig
+	elasticConfig
+	Drop       string `envconfig:"PARTITION_DROP" flag:"drop" desc:"Partition whose indices are deleted to rebuild it, e.g. 2021."`
+	ForceMerge string `envconfig:"PARTITION_FORCE_MERGE" flag:"force-merge" desc:"Partition whose indices are merged into a single segment, e.g. 2021."`
+}
+
+// runPartition lists the partitions of the flights, or deletes or merges the indices of one of them.
+func runPartition(args []string) error {
+	var env partitionConfig
+	if _, err := loadConfig("partition", &env, &env.logConfig, args); err != nil {
+		return err
+	}
+	log.Infof("Elastic endpoint      : %s", env.ElasticEndpoint)
+
+	manager, err := newElasticManager(env.elasticConfig)
+	if err != nil {
+		return fmt.Errorf("error creating the elastic manager: %w", err)
+	}
+	defer manager.Close()
+	ctx := context.Background()
+
+	switch {
+	case env.Drop != "":
+		indices, err := manager.DeletePartition(ctx, env.Drop)
+		if err != nil {
+			return err
+		}
+		log.Infof("Indices %v of partition %s deleted", indices, env.Drop)
+	case env.ForceMerge != "":
+		indices, err := manager.ForceMergePartition(ctx, env.ForceMerge)
+		if err != nil {
+			return err
+		}
+		log.Infof("Indices %v of partition %s merged", indices, env.ForceMerge)
+	default:
+		partitions, err := manager.Partitions(ctx)
+		if err != nil {
+			return err
+		}
+		for _, partition := range partitions {
+			fmt.Fprintf(os.Stdout, "%s\t%s\t%s\n", partition.Alias, partition.WriteIndex, strings.Join(partition.Indices, ","))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+)
+
+type reindexConfig struct {
+	logConfig
+	elasticConfig
+	// Number of flights read at once
+	BatchSize int `envconfig:"BATCH_SIZE" default:"1000"`
+}
+
+// runReindex computes the duration in seconds of the flights indexed without it.
+func runReindex(args []string) error {
+	var env reindexConfig
+	if _, err := loadConfig("reindex", &env, &env.logConfig, args); err != nil {
+		return err
+	}
+	log.Infof("Elastic endpoint      : %s", env.ElasticEndpoint)
+
+	manager, err := newElasticManager(env.elasticConfig)
+	if err != nil {
+		return fmt.Errorf("error creating the elastic manager: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	updated, err := manager.UpdateFlightDurations(ctx, env.BatchSize)
+	if err != nil {
+		return fmt.Errorf("error updating the flight durations (%d updated): %w", updated, err)
+	}
+	log.Infof("Duration of %d flights updated", updated)
+	return nil
+}
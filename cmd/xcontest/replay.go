@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"fahy.xyz/xcontestextractor/deadletter"
+	"fahy.xyz/xcontestextractor/parser"
+	"fahy.xyz/xcontestextractor/store"
+)
+
+type replayConfig struct {
+	logConfig
+	storeConfig
+	crawlConfig
+	// File where the flights which cannot be extracted are saved
+	DeadLetterPath string        `envconfig:"DEADLETTER_PATH" default:"./data/deadletter.jsonl"`
+	Timeout        time.Duration `envconfig:"TIMEOUT" default:"60s"`
+}
+
+// replay extracts again the flight of a dead letter and stores it.
+func replay(ctx context.Context, manager store.FlightStore, fetcher *parser.Fetcher, record deadletter.Record) error {
+	flight, err := fetcher.GetFlightInfo(ctx, record.Url, record.Source)
+	if err != nil {
+		return err
+	}
+	flight.FullName = record.Entry.FullName
+	flight.FlightDate = record.Entry.FlightDate
+	flight.Distance = record.Entry.Distance
+	flight.FlightType = record.Entry.FlightType
+	flight.PublicationDate = record.PublicationDate
+	flight.Url = record.Url
+	if flight.UtcOffset == "" && record.Entry.UtcOffset != "" {
+		if err = flight.SetLaunchTime(record.Url, record.Entry.UtcOffset); err != nil {
+			log.Warningf("Error setting the launch time of %s: %v", record.Url, err)
+		}
+	}
+	if err = manager.InsertFlight(flight); err != nil {
+		return err
+	}
+	if err = manager.UpdatePilots([]*parser.Flight{flight}); err != nil {
+		log.Errorf("Error updating the pilot of flight %s: %v", flight.Url, err)
+	}
+	return nil
+}
+
+// runReplay extracts again the flights of the dead letters, the flights which still fail are written back.
+func runReplay(args []string) error {
+	var env replayConfig
+	if _, err := loadConfig("replay", &env, &env.logConfig, args); err != nil {
+		return err
+	}
+	log.Infof("Dead letters          : %s", env.DeadLetterPath)
+
+	// Initialization of the storage backend.
+	manager, err := newFlightStore(env.storeConfig)
+	if err != nil {
+		return err
+	}
+	defer manager.Close()
+
+	client := &http.Client{Timeout: env.Timeout}
+	fetcher, err := newFetcher(env.crawlConfig, client, "", newPolicy(env.crawlConfig))
+	if err != nil {
+		return err
+	}
+
+	deadLetters, err := deadletter.NewWriter(env.DeadLetterPath)
+	if err != nil {
+		return fmt.Errorf("error creating the dead letters file: %w", err)
+	}
+	records, done, err := deadletter.Take(env.DeadLetterPath)
+	if err != nil {
+		return fmt.Errorf("error reading the dead letters: %w", err)
+	}
+	log.Infof("Replaying %d dead letters", len(records))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	inserted, duplicates := 0, 0
+	var remaining []deadletter.Record
+	for i, record := range records {
+		if ctx.Err() != nil {
+			// Keep the records not replayed yet.
+			remaining = append(remaining, records[i:]...)
+			break
+		}
+		err := replay(ctx, manager, fetcher, record)
+		switch {
+		case errors.Is(err, store.ErrFlightExists):
+			duplicates++
+		case err != nil:
+			log.Errorf("Error replaying flight %s: %v", record.Url, err)
+			failure := deadletter.NewRecord(record.Entry, record.Source, err)
+			failure.PublicationDate = record.PublicationDate
+			remaining = append(remaining, failure)
+		default:
+			inserted++
+		}
+	}
+
+	for _, record := range remaining {
+		if err = deadLetters.Write(record); err != nil {
+			return fmt.Errorf("error writing back dead letter of %s: %w", record.Url, err)
+		}
+	}
+	if err = done(); err != nil {
+		return fmt.Errorf("error deleting the replayed dead letters: %w", err)
+	}
+	log.Infof("Replay done: %d inserted, %d duplicates, %d still failing", inserted, duplicates, len(remaining))
+	return nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"fahy.xyz/xcontestextractor/deadletter"
+	"fahy.xyz/xcontestextractor/metrics"
+	"fahy.xyz/xcontestextractor/parser"
+	"fahy.xyz/xcontestextractor/store"
+	"github.com/procyon-projects/chrono"
+)
+
+const (
+	rssSource string = "rss"
+	// Url of the RSS feed of XContest.
+	rssUrl string = "https://www.xcontest.org/rss/flights/?world"
+)
+
+type rssConfig struct {
+	logConfig
+	extractionConfig
+	// App
+	RunInterval time.Duration `envconfig:"RUN_INTERVAL" default:"5m"`
+}
+
+// runRss extracts the flights of the RSS feed at a fixed interval until a shutdown signal is received.
+func runRss(args []string) error {
+	var env rssConfig
+	if _, err := loadConfig("rss", &env, &env.logConfig, args); err != nil {
+		return err
+	}
+	log.Infof("Running interval      : %s", env.RunInterval)
+
+	startMetrics(env.metricsConfig, "rssextractor")
+
+	// Initialization of the storage backend.
+	manager, err := newFlightStore(env.storeConfig)
+	if err != nil {
+		metrics.ErrorsTotal.Inc()
+		return err
+	}
+	defer manager.Close()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.MaxConnsPerHost = 100
+	transport.MaxIdleConnsPerHost = 100
+
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+	}
+	fetcher, err := newFetcher(env.crawlConfig, client, "", newPolicy(env.crawlConfig))
+	if err != nil {
+		return err
+	}
+	tracks, err := newTrackDownloader(env.extractionConfig, fetcher)
+	if err != nil {
+		return err
+	}
+	deadLetters, err := newDeadLetterWriter(env.extractionConfig)
+	if err != nil {
+		return err
+	}
+
+	// Coordination context, channels and signals
+	ctx, cancel := context.WithCancel(context.Background())
+
+	shutdownChan := make(chan os.Signal, 1)
+	signal.Notify(shutdownChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(shutdownChan)
+
+	taskScheduler := chrono.NewDefaultTaskScheduler()
+
+	// The coordination context is used for the requests since the scheduler never cancels the context of the task.
+	_, err = taskScheduler.ScheduleWithFixedDelay(func(context.Context) {
+		log.Infof("Running extractor at: %v", time.Now())
+		metrics.RunsTotal.Inc()
+
+		// Read the RSS feed.
+		resp, err := fetcher.Get(ctx, rssUrl)
+		metrics.HttpRequestsTotal.Inc()
+		if err != nil {
+			metrics.ErrorsTotal.Inc()
+			log.Errorf("Error requesting url: %v", err)
+			return
+		}
+		defer resp.Body.Close()
+
+		// Extract the flights.
+		items, err := parser.ParseRSS(resp.Body)
+		var rowErrors parser.RowErrors
+		if errors.As(err, &rowErrors) {
+			for _, rowError := range rowErrors {
+				metrics.ErrorsTotal.Inc()
+				log.Errorf("Error parsing the RSS feed: %v", rowError)
+			}
+		} else if err != nil {
+			metrics.ErrorsTotal.Inc()
+			log.Errorf("Error unmarshaling the XML data: %v", err)
+			return
+		}
+		numInsertion := 0
+		// Insert each flight into ES.
+		for i, item := range items {
+			log.Debugf("Processing flight  : %+v (%d / %d)", item, i, len(items))
+
+			flightExists, err := manager.FlightExists(item.Link)
+			if err != nil {
+				metrics.ErrorsTotal.Inc()
+				log.Errorf("Error searching if the flight exists: %v", err)
+				continue
+			}
+			if flightExists {
+				log.Info("Flight already exists, skipping.")
+				metrics.DuplicatesTotal.Inc()
+				continue
+			}
+			log.Infof("Processing url %s", item.Link)
+			flight, err := fetcher.GetFlightInfo(ctx, item.Link, rssSource)
+			metrics.HttpRequestsTotal.Inc()
+			if err != nil {
+				metrics.ErrorsTotal.Inc()
+				log.Errorf("Error getting flight information: %v", err)
+				// The flight will soon leave the feed, keep it to replay it later.
+				record := deadletter.NewRecord(item.Entry(), rssSource, err)
+				record.PublicationDate = item.PublicationDate.UnixMilli()
+				metrics.DeadLettersTotal.Inc()
+				if err = deadLetters.Write(record); err != nil {
+					metrics.ErrorsTotal.Inc()
+					log.Errorf("Error writing dead letter of %s: %v", item.Link, err)
+				}
+				continue
+			}
+
+			flight.FullName = item.FullName
+			flight.FlightDate = item.FlightDate.UnixMilli()
+			flight.Distance = item.Distance
+			flight.FlightType = item.FlightType
+			flight.PublicationDate = item.PublicationDate.UnixMilli()
+			flight.Url = item.Link
+			log.Debugf("Url                : %s", flight.Url)
+			if tracks != nil {
+				tracks.ProcessOrSkip(ctx, flight)
+			}
+
+			err = manager.InsertFlight(flight)
+			if errors.Is(err, store.ErrFlightExists) {
+				log.Info("Flight already exists, skipping.")
+				metrics.DuplicatesTotal.Inc()
+				continue
+			}
+			if err != nil {
+				metrics.ErrorsTotal.Inc()
+				log.Errorf("Error indexing flight into ElasticSearch: %v", err)
+				continue
+			}
+			metrics.DocumentsTotal.Inc()
+			numInsertion++
+			if err = manager.UpdatePilots([]*parser.Flight{flight}); err != nil {
+				metrics.ErrorsTotal.Inc()
+				log.Errorf("Error updating the pilot of flight %s: %v", flight.Url, err)
+			}
+		}
+		log.Infof("Inserted %d flights", numInsertion)
+	}, env.RunInterval)
+
+	if err == nil {
+		log.Info("Task has been scheduled successfully.")
+	}
+
+	select {
+	case <-shutdownChan:
+		log.Info("Shutdown signal received, exiting...")
+		// Cancel first to abort the in-flight requests of the running task.
+		cancel()
+		shutdownSchedulerChan := taskScheduler.Shutdown()
+		<-shutdownSchedulerChan
+		break
+	case <-ctx.Done():
+		log.Info("Group context is done, exiting...")
+		shutdownSchedulerChan := taskScheduler.Shutdown()
+		<-shutdownSchedulerChan
+		cancel()
+		break
+	}
+
+	err = ctx.Err()
+	if err != nil && err != context.Canceled {
+		return err
+	}
+	log.Infof("Shutdown properly completed")
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"syscall"
+
+	"fahy.xyz/xcontestextractor/api"
+)
+
+type serveConfig struct {
+	logConfig
+	elasticConfig
+	metricsConfig
+	// Maximal number of flights returned by a request
+	MaxPageSize int `envconfig:"API_MAX_PAGE_SIZE" default:"1000"`
+}
+
+// runServe serves the indexed flights over HTTP, on the same port as the metrics.
+func runServe(args []string) error {
+	var env serveConfig
+	if _, err := loadConfig("serve", &env, &env.logConfig, args); err != nil {
+		return err
+	}
+	log.Infof("Elastic endpoint      : %s", env.ElasticEndpoint)
+	log.Infof("Port                  : %s", env.Port)
+
+	manager, err := newElasticManager(env.elasticConfig)
+	if err != nil {
+		return fmt.Errorf("error creating the elastic manager: %w", err)
+	}
+	defer manager.Close()
+
+	api.NewServer(manager, env.MaxPageSize).Register(http.DefaultServeMux)
+	startMetrics(env.metricsConfig, "api")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	log.Info("Shutdown signal received, exiting...")
+	return nil
+}
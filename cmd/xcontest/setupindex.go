@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+type setupIndexConfig struct {
+	logConfig
+	elasticConfig
+}
+
+// runSetupIndex creates the templates and the indices of ElasticSearch and checks the mapping of the flights,
+// it can be run several times.
+func runSetupIndex(args []string) error {
+	var env setupIndexConfig
+	if _, err := loadConfig("setup-index", &env, &env.logConfig, args); err != nil {
+		return err
+	}
+	log.Infof("Elastic endpoint      : %s", env.ElasticEndpoint)
+
+	manager, err := newElasticManager(env.elasticConfig)
+	if err != nil {
+		return fmt.Errorf("error creating the elastic manager: %w", err)
+	}
+	if err = manager.EnsureIndices(context.Background(), env.MaxIndexSize); err != nil {
+		return migrateHint(err)
+	}
+	log.Info("Indices successfully set up.")
+	return nil
+}
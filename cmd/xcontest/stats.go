@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"fahy.xyz/xcontestextractor/stats"
+	"fahy.xyz/xcontestextractor/store"
+)
+
+type statsConfig struct {
+	logConfig
+	elasticConfig
+	Format  string `envconfig:"STATS_FORMAT" flag:"format" default:"json" desc:"Format of the statistics: json, csv or markdown."`
+	Output  string `envconfig:"STATS_OUTPUT" flag:"output" desc:"File of the statistics, the standard output if empty."`
+	From    string `envconfig:"STATS_FROM" flag:"from" desc:"First day of the flights, e.g. 2022-01-01."`
+	To      string `envconfig:"STATS_TO" flag:"to" desc:"Last day of the flights, e.g. 2022-12-31."`
+	Country string `envconfig:"STATS_COUNTRY" flag:"country" desc:"Country of the flights, e.g. CH."`
+}
+
+// statsQuery converts the filters of the configuration into a query.
+func statsQuery(env statsConfig) (store.FlightQuery, error) {
+	query := store.FlightQuery{Country: env.Country}
+	if env.From != "" {
+		from, err := time.Parse(dateLayout, env.From)
+		if err != nil {
+			return query, fmt.Errorf("invalid first day %q: %w", env.From, err)
+		}
+		query.From = from.UnixMilli()
+	}
+	if env.To != "" {
+		to, err := time.Parse(dateLayout, env.To)
+		if err != nil {
+			return query, fmt.Errorf("invalid last day %q: %w", env.To, err)
+		}
+		query.To = to.AddDate(0, 0, 1).UnixMilli() - 1
+	}
+	return query, nil
+}
+
+// runStats computes the weekly statistics of the flights by year and country.
+func runStats(args []string) error {
+	var env statsConfig
+	if _, err := loadConfig("stats", &env, &env.logConfig, args); err != nil {
+		return err
+	}
+	// The format is checked before querying ElasticSearch and creating the output file.
+	if err := stats.CheckFormat(env.Format); err != nil {
+		return err
+	}
+	query, err := statsQuery(env)
+	if err != nil {
+		return err
+	}
+	log.Infof("Elastic endpoint      : %s", env.ElasticEndpoint)
+
+	manager, err := newElasticManager(env.elasticConfig)
+	if err != nil {
+		return fmt.Errorf("error creating the elastic manager: %w", err)
+	}
+	defer manager.Close()
+	weeklyStats, err := manager.WeeklyStats(context.Background(), query)
+	if err != nil {
+		return err
+	}
+	stats.Sort(weeklyStats)
+
+	var output io.Writer = os.Stdout
+	if env.Output != "" {
+		file, err := os.Create(env.Output)
+		if err != nil {
+			return fmt.Errorf("error creating the output file: %w", err)
+		}
+		defer file.Close()
+		output = file
+	}
+	if err = stats.Write(output, weeklyStats, env.Format); err != nil {
+		return fmt.Errorf("error writing the statistics: %w", err)
+	}
+	if env.Output != "" {
+		log.Infof("Statistics of %d weeks saved at %s", len(weeklyStats), env.Output)
+	}
+	return nil
+}
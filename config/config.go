@@ -0,0 +1,219 @@
+// Package config loads the configuration of the commands from flags, environment variables and a YAML file.
+//
+// The settings are the fields of a struct, named by their `envconfig` tag and optionally set by a `default` tag.
+// The name of the flag is the lowercase name of the variable with dashes, e.g. `--elasticsearch-url`, and the key
+// in the YAML file is the lowercase name, e.g. `elasticsearch_url`. They can be changed with the `flag` and `yaml` tags.
+// The values are taken in order from the flags, the environment variables, the YAML file and the defaults.
+// Embedded structs are loaded as part of the parent struct.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FileFlag is the flag with the path of the YAML file.
+	FileFlag string = "config"
+	// FileEnv is the environment variable with the path of the YAML file, used if the flag is not set.
+	FileEnv string = "XCONTEST_CONFIG"
+)
+
+// setting is a field of the configuration.
+type setting struct {
+	value      reflect.Value
+	env        string
+	flag       string
+	yaml       string
+	defaultVal string
+	desc       string
+}
+
+// flagValue is the raw value of a flag, boolean flags can be set without value, e.g. `--restart`.
+type flagValue struct {
+	raw    string
+	isBool bool
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.raw
+}
+
+func (v *flagValue) Set(raw string) error {
+	v.raw = raw
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// settings lists the fields of the configuration, including the fields of the embedded structs.
+func settings(value reflect.Value) []setting {
+	var result []setting
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		// The exported fields of embedded structs are settable even if the struct is not exported.
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			result = append(result, settings(value.Field(i))...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		env, ok := field.Tag.Lookup("envconfig")
+		if !ok {
+			continue
+		}
+		s := setting{
+			value:      value.Field(i),
+			env:        env,
+			flag:       strings.ReplaceAll(strings.ToLower(env), "_", "-"),
+			yaml:       strings.ToLower(env),
+			defaultVal: field.Tag.Get("default"),
+			desc:       field.Tag.Get("desc"),
+		}
+		if name, ok := field.Tag.Lookup("flag"); ok {
+			s.flag = name
+		}
+		if name, ok := field.Tag.Lookup("yaml"); ok {
+			s.yaml = name
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+// Load fills the configuration pointed by spec from the arguments of the command, the environment and the YAML file.
+//
+// The remaining arguments, after the flags, are returned.
+func Load(name string, spec interface{}, args []string) ([]string, error) {
+	value := reflect.ValueOf(spec)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("the configuration must be a pointer to a struct, got %T", spec)
+	}
+	fields := settings(value.Elem())
+
+	// Flags are kept as raw strings to know which ones are set and to parse them like the other values.
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := flags.String(FileFlag, "", fmt.Sprintf("Path of the YAML configuration file (env %s).", FileEnv))
+	flagValues := make([]*flagValue, len(fields))
+	for i, s := range fields {
+		usage := fmt.Sprintf("%s (env %s)", s.desc, s.env)
+		if s.desc == "" {
+			usage = fmt.Sprintf("Env %s.", s.env)
+		}
+		flagValues[i] = &flagValue{raw: s.defaultVal, isBool: s.value.Kind() == reflect.Bool}
+		flags.Var(flagValues[i], s.flag, usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	setFlags := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
+
+	path := *file
+	if path == "" {
+		path = os.Getenv(FileEnv)
+	}
+	var yamlValues map[string]string
+	if path != "" {
+		var err error
+		if yamlValues, err = ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, s := range fields {
+		raw, source := s.defaultVal, "default"
+		if yamlValue, ok := yamlValues[s.yaml]; ok {
+			raw, source = yamlValue, "file"
+		}
+		if envValue, ok := os.LookupEnv(s.env); ok {
+			raw, source = envValue, "env"
+		}
+		if setFlags[s.flag] {
+			raw, source = flagValues[i].raw, "flag"
+		}
+		if raw == "" && source == "default" {
+			continue
+		}
+		if err := setValue(s.value, raw); err != nil {
+			return nil, fmt.Errorf("invalid value %q of %s (%s): %w", raw, s.env, source, err)
+		}
+	}
+	return flags.Args(), nil
+}
+
+// ReadFile reads the raw values of a YAML configuration file.
+//
+// The file is a flat map of the settings, e.g. `elasticsearch_url: http://127.0.0.1:9200`.
+// The values are kept as written, e.g. a date is not converted to a timestamp.
+func ReadFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the configuration file: %w", err)
+	}
+	nodes := make(map[string]yaml.Node)
+	if err = yaml.Unmarshal(content, &nodes); err != nil {
+		return nil, fmt.Errorf("error parsing the configuration file %s: %w", path, err)
+	}
+	values := make(map[string]string, len(nodes))
+	for key, node := range nodes {
+		if node.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("error parsing the configuration file %s: %s is not a single value", path, key)
+		}
+		values[key] = node.Value
+	}
+	return values, nil
+}
+
+// setValue converts a raw value to the type of the field.
+func setValue(field reflect.Value, raw string) error {
+	// Durations are int64 but set with their string representation, e.g. `5m`.
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(value)
+	case reflect.Int, reflect.Int64:
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(value)
+	case reflect.Float64:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(value)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+type elasticConfig struct {
+	ElasticEndpoint string `envconfig:"ELASTICSEARCH_URL" default:"http://127.0.0.1:9200"`
+}
+
+type testConfig struct {
+	elasticConfig
+	LogLevel    string        `envconfig:"LOG_LEVEL" default:"info"`
+	RunInterval time.Duration `envconfig:"RUN_INTERVAL" default:"5m"`
+	Parallelism int           `envconfig:"PARALLELISM" default:"4"`
+	Rate        float64       `envconfig:"RATE" default:"1.5"`
+	Restart     bool          `envconfig:"RESTART"`
+	From        string        `envconfig:"FROM_DATE" flag:"from"`
+}
+
+func TestLoadDefaults(t *testing.T) {
+	var config testConfig
+	if _, err := Load("test", &config, nil); err != nil {
+		t.Fatalf("Error loading the configuration: %v", err)
+	}
+	expected := testConfig{
+		elasticConfig: elasticConfig{ElasticEndpoint: "http://127.0.0.1:9200"},
+		LogLevel:      "info",
+		RunInterval:   5 * time.Minute,
+		Parallelism:   4,
+		Rate:          1.5,
+	}
+	if config != expected {
+		t.Errorf("Wrong configuration: %+v", config)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("PARALLELISM", "2")
+	var config testConfig
+	args, err := Load("test", &config, []string{"--config", "testdata/config.yaml", "--parallelism", "16", "--restart", "extra"})
+	if err != nil {
+		t.Fatalf("Error loading the configuration: %v", err)
+	}
+	expected := testConfig{
+		// From the file.
+		elasticConfig: elasticConfig{ElasticEndpoint: "http://elasticsearch:9200"},
+		RunInterval:   time.Minute,
+		From:          "2022-05-01",
+		// From the environment, over the file.
+		LogLevel: "warn",
+		// From the flags, over the environment.
+		Parallelism: 16,
+		Restart:     true,
+		// Default.
+		Rate: 1.5,
+	}
+	if config != expected {
+		t.Errorf("Wrong configuration: %+v", config)
+	}
+	if len(args) != 1 || args[0] != "extra" {
+		t.Errorf("Wrong remaining arguments: %v", args)
+	}
+}
+
+func TestLoadFileFromEnv(t *testing.T) {
+	t.Setenv(FileEnv, "testdata/config.yaml")
+	var config testConfig
+	if _, err := Load("test", &config, []string{"--from", "2021-01-01"}); err != nil {
+		t.Fatalf("Error loading the configuration: %v", err)
+	}
+	if config.LogLevel != "debug" || config.From != "2021-01-01" {
+		t.Errorf("Wrong configuration: %+v", config)
+	}
+}
+
+func TestLoadInvalidValue(t *testing.T) {
+	t.Setenv("PARALLELISM", "many")
+	var config testConfig
+	if _, err := Load("test", &config, nil); err == nil {
+		t.Error("Expected an error for an invalid integer")
+	}
+}
@@ -0,0 +1,222 @@
+package crawl
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"fahy.xyz/xcontestextractor/metrics"
+	"github.com/sqooba/go-common/logging"
+)
+
+var (
+	log = logging.NewLogger()
+)
+
+// Config contains the settings of the crawling policy.
+type Config struct {
+	// RequestsPerSecond is the rate of requests allowed per host, no limit if zero.
+	RequestsPerSecond float64
+	// Burst is the number of requests which can be done at once.
+	Burst int
+	// Jitter is the maximal random delay added before each request.
+	Jitter time.Duration
+	// MaxRetries is the number of retries of a throttled or failed request.
+	MaxRetries int
+	// InitialBackoff is the delay after the first failure, doubled on each consecutive failure.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximal delay between two requests after failures.
+	MaxBackoff time.Duration
+}
+
+// hostState is the state of the token bucket of a host.
+type hostState struct {
+	tokens       float64
+	lastRefill   time.Time
+	blockedUntil time.Time
+	failures     int
+}
+
+// Policy limits the requests done to each host to crawl politely.
+//
+// Each host has a token bucket refilled at the configured rate, and the requests
+// are delayed after failures with an exponential backoff or the Retry-After of the server.
+type Policy struct {
+	config Config
+	mutex  sync.Mutex
+	hosts  map[string]*hostState
+}
+
+// NewPolicy creates a new instance of the Policy.
+func NewPolicy(config Config) *Policy {
+	if config.Burst < 1 {
+		config.Burst = 1
+	}
+	return &Policy{
+		config: config,
+		hosts:  make(map[string]*hostState),
+	}
+}
+
+// MaxRetries returns the number of retries allowed for a request.
+func (p *Policy) MaxRetries() int {
+	return p.config.MaxRetries
+}
+
+// getHost returns the state of the host, creating it with a full bucket if needed.
+func (p *Policy) getHost(host string, now time.Time) *hostState {
+	state, ok := p.hosts[host]
+	if !ok {
+		state = &hostState{tokens: float64(p.config.Burst), lastRefill: now}
+		p.hosts[host] = state
+	}
+	return state
+}
+
+// refill adds the tokens accumulated since the last refill.
+func (p *Policy) refill(state *hostState, now time.Time) {
+	elapsed := now.Sub(state.lastRefill).Seconds()
+	state.tokens = math.Min(float64(p.config.Burst), state.tokens+elapsed*p.config.RequestsPerSecond)
+	state.lastRefill = now
+}
+
+// report exposes the state of the host as metrics.
+func (p *Policy) report(host string, state *hostState, now time.Time) {
+	if metrics.CrawlTokens == nil {
+		return
+	}
+	metrics.CrawlTokens.WithLabelValues(host).Set(state.tokens)
+	metrics.CrawlBackoffSeconds.WithLabelValues(host).Set(math.Max(0, state.blockedUntil.Sub(now).Seconds()))
+}
+
+// reserve takes a token for the host, or returns the time to wait before retrying.
+func (p *Policy) reserve(host string) time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	now := time.Now()
+	state := p.getHost(host, now)
+	defer p.report(host, state, now)
+	if now.Before(state.blockedUntil) {
+		return state.blockedUntil.Sub(now)
+	}
+	if p.config.RequestsPerSecond <= 0 {
+		return 0
+	}
+	p.refill(state, now)
+	if state.tokens >= 1 {
+		state.tokens--
+		return 0
+	}
+	return time.Duration((1 - state.tokens) / p.config.RequestsPerSecond * float64(time.Second))
+}
+
+// sleep waits for the given duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Wait blocks until a request to the host is allowed.
+func (p *Policy) Wait(ctx context.Context, host string) error {
+	for {
+		wait := p.reserve(host)
+		if wait == 0 {
+			break
+		}
+		log.Debugf("Waiting %s before requesting %s", wait, host)
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+	if p.config.Jitter > 0 {
+		return sleep(ctx, time.Duration(rand.Int63n(int64(p.config.Jitter))))
+	}
+	return ctx.Err()
+}
+
+// Backoff delays the next requests to the host after a failure.
+//
+// The delay is retryAfter if set, else it grows exponentially with the number of consecutive failures.
+func (p *Policy) Backoff(host string, retryAfter time.Duration) time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	now := time.Now()
+	state := p.getHost(host, now)
+	delay := retryAfter
+	if delay <= 0 {
+		exponent := state.failures
+		if exponent > 30 {
+			exponent = 30
+		}
+		delay = p.config.InitialBackoff * time.Duration(1<<uint(exponent))
+	}
+	if p.config.MaxBackoff > 0 && delay > p.config.MaxBackoff {
+		delay = p.config.MaxBackoff
+	}
+	state.failures++
+	if until := now.Add(delay); until.After(state.blockedUntil) {
+		state.blockedUntil = until
+	}
+	log.Warningf("Backing off from %s for %s (%d consecutive failures)", host, delay, state.failures)
+	p.report(host, state, now)
+	return delay
+}
+
+// Throttle records a request throttled by the host and backs off.
+func (p *Policy) Throttle(host string, retryAfter time.Duration) time.Duration {
+	if metrics.CrawlThrottledTotal != nil {
+		metrics.CrawlThrottledTotal.WithLabelValues(host).Inc()
+	}
+	return p.Backoff(host, retryAfter)
+}
+
+// Success resets the consecutive failures of the host.
+func (p *Policy) Success(host string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.getHost(host, time.Now()).failures = 0
+}
+
+// Throttled reports whether the response asks the client to slow down.
+func Throttled(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
+
+// ParseRetryAfter parses the value of a Retry-After header, either in seconds or as a HTTP date.
+//
+// Zero is returned if the value is missing or invalid.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// Host returns the host of the url, used as key of the policy.
+func Host(rawUrl string) string {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	return parsed.Host
+}
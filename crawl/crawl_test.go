@@ -0,0 +1,51 @@
+package crawl
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 11, 13, 14, 20, 0, 0, time.UTC)
+	if d := ParseRetryAfter("120", now); d != 2*time.Minute {
+		t.Errorf("Retry-After in seconds is wrong: %s", d)
+	}
+	if d := ParseRetryAfter("Sat, 13 Nov 2021 14:21:00 GMT", now); d != time.Minute {
+		t.Errorf("Retry-After as date is wrong: %s", d)
+	}
+	if d := ParseRetryAfter("soon", now); d != 0 {
+		t.Errorf("Invalid Retry-After is not ignored: %s", d)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := NewPolicy(Config{InitialBackoff: time.Second, MaxBackoff: 3 * time.Second})
+	expected := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	for i, delay := range expected {
+		if d := policy.Backoff("www.xcontest.org", 0); d != delay {
+			t.Errorf("Backoff %d is wrong: %s != %s", i, d, delay)
+		}
+	}
+	if d := policy.Backoff("www.xcontest.org", 2*time.Second); d != 2*time.Second {
+		t.Errorf("Retry-After is not used as backoff: %s", d)
+	}
+	policy.Success("www.xcontest.org")
+	if d := policy.Backoff("www.xcontest.org", 0); d != time.Second {
+		t.Errorf("Backoff is not reset after a success: %s", d)
+	}
+}
+
+func TestWaitRateLimit(t *testing.T) {
+	policy := NewPolicy(Config{RequestsPerSecond: 20, Burst: 2})
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := policy.Wait(context.Background(), "www.xcontest.org"); err != nil {
+			t.Fatalf("Error waiting: %v", err)
+		}
+	}
+	// Two requests are in the burst, the two others wait 50ms each.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Requests are not rate limited: %s", elapsed)
+	}
+}
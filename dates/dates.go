@@ -0,0 +1,111 @@
+package dates
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	// LocalTimeLayout is the format of the local times, without offset.
+	LocalTimeLayout = "2006-01-02T15:04:05"
+)
+
+var (
+	// Layouts of the dates of the flights, e.g. `7.11.2021` in the urls or `07.11.21` in the RSS feed.
+	flightDateLayouts = []string{"2.1.2006", "2.1.06"}
+	// Layouts of the publication dates of the RSS feed.
+	pubDateLayouts = []string{time.RFC1123Z, time.RFC1123}
+
+	regexLaunch    = regexp.MustCompile(`detail:[^/]+/([0-9.]+)/([0-9]{1,2}:[0-9]{2})`)
+	regexUtcOffset = regexp.MustCompile(`(?:UTC ?)?([+-])([0-9]{1,2}):?([0-9]{2})?`)
+	// UTC displayed without offset, e.g. in the United Kingdom in winter.
+	regexUtc = regexp.MustCompile(`\bUTC\b`)
+)
+
+// ParseFlightDate parses the date of a flight, e.g. `7.11.2021` or `07.11.21`.
+//
+// The date is returned at midnight UTC.
+func ParseFlightDate(value string) (time.Time, error) {
+	for _, layout := range flightDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("unrecognized time format")
+}
+
+// ParsePubDate parses the publication date of an item of the RSS feed, e.g. `Sat, 13 Nov 2021 14:20:13 +0000`.
+//
+// The date is returned in UTC.
+func ParsePubDate(value string) (time.Time, error) {
+	var err error
+	for _, layout := range pubDateLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// ParseUtcOffset converts an offset displayed by XContest, e.g. `UTC-03:00`, `-03:00` or `UTC`, into a location.
+func ParseUtcOffset(value string) (*time.Location, error) {
+	match := regexUtcOffset.FindStringSubmatch(value)
+	if len(match) == 0 {
+		if regexUtc.MatchString(value) {
+			return time.FixedZone(FormatOffset(0), 0), nil
+		}
+		return nil, fmt.Errorf("unrecognized UTC offset: %s", value)
+	}
+	hours, err := strconv.Atoi(match[2])
+	if err != nil {
+		return nil, err
+	}
+	minutes := 0
+	if match[3] != "" {
+		if minutes, err = strconv.Atoi(match[3]); err != nil {
+			return nil, err
+		}
+	}
+	seconds := hours*3600 + minutes*60
+	if match[1] == "-" {
+		seconds = -seconds
+	}
+	return time.FixedZone(FormatOffset(seconds), seconds), nil
+}
+
+// FormatOffset formats an offset in seconds, e.g. `-03:00`.
+func FormatOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign = '-'
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%c%02d:%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+// LaunchTime extracts the local date and time of the launch from the url of a flight,
+// e.g. `detail:Nicober/7.11.2021/13:13`.
+//
+// The time is in the given location, the one of the take-off, or in UTC if it is nil.
+func LaunchTime(url string, location *time.Location) (time.Time, error) {
+	match := regexLaunch.FindStringSubmatch(url)
+	if len(match) == 0 {
+		return time.Time{}, fmt.Errorf("no launch time in url: %s", url)
+	}
+	date, err := ParseFlightDate(match[1])
+	if err != nil {
+		return time.Time{}, err
+	}
+	clock, err := time.Parse("15:04", match[2])
+	if err != nil {
+		return time.Time{}, err
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, location), nil
+}
@@ -0,0 +1,94 @@
+package dates
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFlightDate(t *testing.T) {
+	expected := time.Date(2021, 11, 7, 0, 0, 0, 0, time.UTC)
+	for _, input := range []string{"7.11.2021", "07.11.2021", "07.11.21"} {
+		result, err := ParseFlightDate(input)
+		if err != nil {
+			t.Errorf("Error parsing the date %s: %v", input, err)
+		}
+		if !result.Equal(expected) {
+			t.Errorf("Parsed date of %s is wrong: %s", input, result)
+		}
+	}
+	if _, err := ParseFlightDate("2021-11-07"); err == nil {
+		t.Errorf("Invalid date parsed")
+	}
+}
+
+func TestParsePubDate(t *testing.T) {
+	tests := map[string]time.Time{
+		"Sat, 13 Nov 2021 14:20:13 +0000": time.Date(2021, 11, 13, 14, 20, 13, 0, time.UTC),
+		"Sat, 13 Nov 2021 14:20:13 +0100": time.Date(2021, 11, 13, 13, 20, 13, 0, time.UTC),
+		"Sat, 13 Nov 2021 14:20:13 GMT":   time.Date(2021, 11, 13, 14, 20, 13, 0, time.UTC),
+	}
+	for input, expected := range tests {
+		result, err := ParsePubDate(input)
+		if err != nil {
+			t.Errorf("Error parsing the date %s: %v", input, err)
+		}
+		if !result.Equal(expected) {
+			t.Errorf("Parsed date of %s is wrong: %s", input, result)
+		}
+	}
+}
+
+func TestParseUtcOffset(t *testing.T) {
+	tests := map[string]int{
+		"UTC-03:00": -3 * 3600,
+		"UTC+05:30": 5*3600 + 30*60,
+		"UTC+9":     9 * 3600,
+		"-03:00":    -3 * 3600,
+		"UTC":       0,
+		"UTC+0":     0,
+		" UTC ":     0,
+	}
+	for input, expected := range tests {
+		location, err := ParseUtcOffset(input)
+		if err != nil {
+			t.Fatalf("Error parsing the offset %s: %v", input, err)
+		}
+		if _, offset := time.Date(2021, 1, 1, 0, 0, 0, 0, location).Zone(); offset != expected {
+			t.Errorf("Parsed offset of %s is wrong: %d", input, offset)
+		}
+	}
+	for _, input := range []string{"GMT", "UTCX"} {
+		if _, err := ParseUtcOffset(input); err == nil {
+			t.Errorf("Invalid offset %s parsed", input)
+		}
+	}
+	if location, _ := ParseUtcOffset("UTC"); location.String() != "+00:00" {
+		t.Errorf("Wrong name of the UTC offset: %s", location)
+	}
+}
+
+func TestLaunchTime(t *testing.T) {
+	url := "https://www.xcontest.org/world/en/flights/detail:Claricegomes/5.12.2021/14:23"
+	location, _ := ParseUtcOffset("UTC-03:00")
+	launch, err := LaunchTime(url, location)
+	if err != nil {
+		t.Fatalf("Error extracting the launch time: %v", err)
+	}
+	if launch.Format(LocalTimeLayout) != "2021-12-05T14:23:00" {
+		t.Errorf("Local launch time is wrong: %s", launch)
+	}
+	if !launch.Equal(time.Date(2021, 12, 5, 17, 23, 0, 0, time.UTC)) {
+		t.Errorf("UTC launch time is wrong: %s", launch.UTC())
+	}
+
+	// Flights in Asia launch on the previous day in UTC.
+	location, _ = ParseUtcOffset("UTC+09:00")
+	launch, _ = LaunchTime("https://www.xcontest.org/world/en/flights/detail:Pilot/7.11.2021/7:05", location)
+	if !launch.Equal(time.Date(2021, 11, 6, 22, 5, 0, 0, time.UTC)) {
+		t.Errorf("UTC launch time is wrong: %s", launch.UTC())
+	}
+
+	if _, err = LaunchTime("https://www.xcontest.org/world/en/flights/", nil); err == nil {
+		t.Errorf("Launch time extracted from an url without detail")
+	}
+}
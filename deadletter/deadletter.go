@@ -0,0 +1,130 @@
+package deadletter
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"fahy.xyz/xcontestextractor/parser"
+)
+
+// Record is a flight which could not be extracted.
+//
+// It contains everything needed to process the flight again once the cause is fixed.
+type Record struct {
+	Url             string       `json:"url"`
+	Source          string       `json:"source"`
+	Content         string       `json:"content,omitempty"`
+	Error           string       `json:"error"`
+	Timestamp       int64        `json:"timestamp"`
+	Entry           parser.Entry `json:"entry"`
+	PublicationDate int64        `json:"publication_date,omitempty"`
+}
+
+// NewRecord creates the record of a flight which failed with the given error.
+//
+// The raw content of the page is kept when the error is a parser.ParseError.
+func NewRecord(entry parser.Entry, source string, err error) Record {
+	record := Record{
+		Url:       entry.Link,
+		Source:    source,
+		Error:     err.Error(),
+		Timestamp: time.Now().UnixMilli(),
+		Entry:     entry,
+	}
+	var parseError *parser.ParseError
+	if errors.As(err, &parseError) {
+		record.Content = parseError.Content
+	}
+	return record
+}
+
+// Writer appends the records to a JSONL file.
+//
+// It is safe to use from multiple goroutines.
+type Writer struct {
+	mutex sync.Mutex
+	path  string
+}
+
+// NewWriter creates a new instance of the Writer, creating the directory of the file if needed.
+func NewWriter(path string) (*Writer, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return &Writer{path: path}, nil
+}
+
+// Write appends a record to the file.
+func (w *Writer) Write(record Record) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	file, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if err = json.NewEncoder(file).Encode(record); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ReadRecords reads all the records of a file.
+//
+// No record is returned if the file does not exist.
+func ReadRecords(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	// The raw content of the pages can be longer than the default limit of a line.
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record Record
+		if err = json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// Take moves the records of a file aside to process them again.
+//
+// The records written meanwhile go to a new file. Once the records are processed,
+// the returned function must be called to delete them; if it is not called, e.g. on a crash,
+// the same records are returned by the next call.
+func Take(path string) ([]Record, func() error, error) {
+	pendingPath := path + ".replay"
+	if _, err := os.Stat(pendingPath); errors.Is(err, os.ErrNotExist) {
+		if err = os.Rename(path, pendingPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, nil, err
+		}
+	}
+	records, err := ReadRecords(pendingPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	done := func() error {
+		if err := os.Remove(pendingPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return records, done, nil
+}
@@ -0,0 +1,44 @@
+package deadletter
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"fahy.xyz/xcontestextractor/parser"
+)
+
+func TestWriteAndTake(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deadletter.jsonl")
+	writer, err := NewWriter(path)
+	if err != nil {
+		t.Fatalf("Error creating the writer: %v", err)
+	}
+	entry := parser.Entry{FullName: "Nicolas Berardini", Link: "https://www.xcontest.org/world/en/flights/detail:Nicober/7.11.2021/13:13"}
+	parseError := &parser.ParseError{Url: entry.Link, Content: "PARAGLIDING ⛳ Fiesch", Err: errors.New("no country")}
+	if err = writer.Write(NewRecord(entry, "rss", parseError)); err != nil {
+		t.Fatalf("Error writing the record: %v", err)
+	}
+
+	records, done, err := Take(path)
+	if err != nil {
+		t.Fatalf("Error taking the records: %v", err)
+	}
+	if len(records) != 1 || records[0].Content != "PARAGLIDING ⛳ Fiesch" || records[0].Entry != entry {
+		t.Errorf("Records are wrong: %+v", records)
+	}
+	// Records written during the replay are kept for the next one.
+	if err = writer.Write(NewRecord(entry, "rss", errors.New("timeout"))); err != nil {
+		t.Fatalf("Error writing the record: %v", err)
+	}
+	if err = done(); err != nil {
+		t.Fatalf("Error deleting the records: %v", err)
+	}
+	records, _, err = Take(path)
+	if err != nil {
+		t.Fatalf("Error taking the records: %v", err)
+	}
+	if len(records) != 1 || records[0].Error != "timeout" {
+		t.Errorf("Records written during the replay are wrong: %+v", records)
+	}
+}
@@ -1,3 +0,0 @@
-#!/usr/bin/env sh
-
-/opt/es_script/create_index_templates.sh $ES_CLUSTER_URL $ES_PREFIX
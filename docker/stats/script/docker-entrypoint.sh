@@ -1,4 +1,3 @@
 #!/usr/bin/env sh
 
-/opt/script/weekly_stats.sh $ES_CLUSTER_URL
-python /opt/script/transformer.py /output/weekly_stats.raw.json /output/weekly_stats.processed.json
+/xcontest stats --elasticsearch-url "$ES_CLUSTER_URL" --output /output/weekly_stats.processed.json
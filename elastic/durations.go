@@ -0,0 +1,91 @@
+package elastic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"fahy.xyz/xcontestextractor/parser"
+	"github.com/elastic/go-elasticsearch/v8/esutil"
+)
+
+// UpdateFlightDurations sets the duration in seconds of the flights indexed before it was extracted.
+//
+// The mapping of the field is added to the indices behind the alias, then the flights without it
+// are updated in batches from their displayed duration. The number of updated flights is returned.
+func (manager *ElasticManager) UpdateFlightDurations(ctx context.Context, batchSize int) (int, error) {
+	mapping := `{"properties": {"flight_duration_seconds": {"type": "long"}}}`
+	res, err := manager.client.Indices.PutMapping(
+		[]string{manager.indexName},
+		strings.NewReader(mapping),
+		manager.client.Indices.PutMapping.WithContext(ctx),
+	)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	if res.IsError() {
+		return 0, fmt.Errorf("error updating the mapping of %s: %s", manager.indexName, res.Status())
+	}
+
+	var updated, failed int64
+	indexer, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
+		Client:        manager.client,
+		NumWorkers:    1,
+		FlushBytes:    manager.bulk.FlushBytes,
+		FlushInterval: manager.bulk.FlushInterval,
+		OnError: func(ctx context.Context, err error) {
+			log.Errorf("Bulk indexer error: %v", err)
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	query := `{"query": {"bool": {
+		"must": {"exists": {"field": "flight_duration"}},
+		"must_not": {"exists": {"field": "flight_duration_seconds"}}
+	}}}`
+	err = manager.scroll(ctx, query, batchSize, func(hits []searchHit) error {
+		for _, hit := range hits {
+			url := hit.Source.Url
+			seconds, err := parser.ParseDuration(hit.Source.FlightDuration)
+			if err != nil {
+				log.Errorf("Error converting the duration of flight %s: %v", url, err)
+				atomic.AddInt64(&failed, 1)
+				continue
+			}
+			err = indexer.Add(ctx, esutil.BulkIndexerItem{
+				Action:     "update",
+				Index:      hit.Index,
+				DocumentID: hit.Id,
+				Body:       strings.NewReader(fmt.Sprintf(`{"doc": {"flight_duration_seconds": %d}}`, seconds)),
+				OnSuccess: func(ctx context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem) {
+					atomic.AddInt64(&updated, 1)
+				},
+				OnFailure: func(ctx context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem, err error) {
+					if err == nil {
+						err = fmt.Errorf("status %d: %s: %s", res.Status, res.Error.Type, res.Error.Reason)
+					}
+					log.Errorf("Error updating flight %s: %v", url, err)
+					atomic.AddInt64(&failed, 1)
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if closeErr := indexer.Close(ctx); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return int(updated), err
+	}
+	if failed > 0 {
+		return int(updated), fmt.Errorf("%d flights failed to be updated", failed)
+	}
+	return int(updated), nil
+}
@@ -8,11 +8,13 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
+	"time"
 
 	"fahy.xyz/xcontestextractor/parser"
+	"fahy.xyz/xcontestextractor/store"
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esutil"
-	"github.com/mottaquikarim/esquerydsl"
 	"github.com/sqooba/go-common/logging"
 )
 
@@ -27,25 +29,32 @@ const (
 type ElasticManager struct {
 	client    *elasticsearch.Client
 	indexName string
+	bulk      BulkConfig
+	// Backing indices of the flights, and the aliases of the partitions already created.
+	partitioning Partitioning
+	partitions   sync.Map
 }
 
-type SearchResults struct {
-	Hits struct {
-		Total struct {
-			Value int `json:"value"`
-		} `json:"total"`
-	} `json:"hits"`
+// BulkConfig contains the settings of the bulk indexer used by InsertFlights.
+type BulkConfig struct {
+	// FlushBytes is the size of the buffer triggering a flush of the bulk request.
+	FlushBytes int
+	// FlushInterval is the maximal duration between two flushes.
+	FlushInterval time.Duration
 }
 
-type LastFlightNumber struct {
-	Source struct {
-		Year             int `json:"year"`
-		LastFlightNumber int `json:"last_flight_number"`
-	} `json:"_source"`
+type CountResults struct {
+	Count int `json:"count"`
+}
+
+type DownloadStateResult struct {
+	Source store.DownloadState `json:"_source"`
 }
 
 // NewElasticManager creates a new instance of the ElasticManager.
-func NewElasticManager(endpoint string, username string, password string, indexName string) (ElasticManager, error) {
+//
+// The flights are searched through the indexName alias and written through the alias of their partition.
+func NewElasticManager(endpoint string, username string, password string, indexName string, bulk BulkConfig, partitioning Partitioning) (*ElasticManager, error) {
 	cfg := elasticsearch.Config{
 		Addresses: []string{
 			endpoint,
@@ -55,145 +64,329 @@ func NewElasticManager(endpoint string, username string, password string, indexN
 	}
 	es, err := elasticsearch.NewClient(cfg)
 	if err != nil {
-		return ElasticManager{}, err
+		return nil, err
 	}
-	client := ElasticManager{
-		client:    es,
-		indexName: indexName,
+	client := &ElasticManager{
+		client:       es,
+		indexName:    indexName,
+		bulk:         bulk,
+		partitioning: partitioning,
 	}
 	return client, nil
 }
 
 // FlightExists check if a flight already exist.
 //
-// The comparison is done with the full name, the distance and the date of the flight.
-func (manager *ElasticManager) FlightExists(fullName string, distance float64, date int64) (bool, error) {
-	// Build the request body.
-	query, _ := json.Marshal(esquerydsl.QueryDoc{
-		Index: manager.indexName,
-		And: []esquerydsl.QueryItem{
-			{
-				Field: "full_name",
-				Value: fullName,
-				Type:  esquerydsl.Match,
-			},
-			{
-				Field: "distance",
-				Value: distance,
-				Type:  esquerydsl.Match,
-			},
-			{
-				Field: "flight_date",
-				Value: date,
-				Type:  esquerydsl.Match,
-			},
-		},
-	})
-	log.Debugf("Elasticsearch query: %s", string(query))
-	// Perform the search request.
-	res, err := manager.client.Search(
-		manager.client.Search.WithContext(context.Background()),
-		manager.client.Search.WithIndex(manager.indexName),
-		manager.client.Search.WithBody(bytes.NewReader(query)),
-		manager.client.Search.WithTrackTotalHits(true),
-		manager.client.Search.WithPretty(),
+// The flight is looked up by its id, derived from the url of the flight.
+func (manager *ElasticManager) FlightExists(url string) (bool, error) {
+	id, err := getFlightId(url)
+	if err != nil {
+		return false, err
+	}
+	// An ids query is used instead of a get since the alias can point to multiple indices.
+	query := fmt.Sprintf(`{"query": {"ids": {"values": [%q]}}}`, id)
+	log.Debugf("Elasticsearch query: %s", query)
+	res, err := manager.client.Count(
+		manager.client.Count.WithContext(context.Background()),
+		manager.client.Count.WithIndex(manager.indexName),
+		manager.client.Count.WithBody(strings.NewReader(query)),
 	)
 	if err != nil {
 		return false, err
 	}
 	defer res.Body.Close()
 	if res.StatusCode == 200 {
-		var hits SearchResults
-		if err = json.NewDecoder(res.Body).Decode(&hits); err != nil {
-			return false, nil
-		}
-		if hits.Hits.Total.Value > 0 {
-			return true, nil
+		var count CountResults
+		if err = json.NewDecoder(res.Body).Decode(&count); err != nil {
+			return false, err
 		}
+		return count.Count > 0, nil
 	}
 	// Read the content of the body before closing.
 	_, err = io.Copy(io.Discard, res.Body)
 	if err != nil {
 		return false, err
 	}
-	return false, nil
+	return false, fmt.Errorf("error searching flight %s: %s", url, res.Status())
+}
+
+// existingIds returns the ids of the flights already stored, in any index of the alias.
+func (manager *ElasticManager) existingIds(ctx context.Context, ids []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(ids) == 0 {
+		return existing, nil
+	}
+	body := map[string]interface{}{
+		"query":   map[string]interface{}{"ids": map[string]interface{}{"values": ids}},
+		"size":    len(ids),
+		"_source": false,
+	}
+	var results flightResults
+	if err := manager.search(ctx, body, &results); err != nil {
+		return nil, fmt.Errorf("error searching the existing flights: %w", err)
+	}
+	for _, hit := range results.Hits.Hits {
+		existing[hit.Id] = true
+	}
+	return existing, nil
+}
+
+// getFlightId compute the hash (id) of a flight.
+func getFlightId(url string) (string, error) {
+	key, err := parser.ExtractFlightKey(url)
+	if err != nil {
+		return "", err
+	}
+	h := md5.New()
+	if _, err := io.WriteString(h, key); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
 
-// GetStateId compute the hash (id) of a document.
-func getStateId(year int) (string, error) {
+// GetStateId compute the hash (id) of a document from the key of the checkpoint.
+func getStateId(key string) (string, error) {
 	h := md5.New()
-	if _, err := io.WriteString(h, fmt.Sprintf("%v-%v", stateIndexName, year)); err != nil {
+	if _, err := io.WriteString(h, fmt.Sprintf("%v-%v", stateIndexName, key)); err != nil {
 		return "", err
 	}
 	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
 
-// GetLastFlightNumber retrieve the number of the last flight processed for the given year.
-func (manager *ElasticManager) GetLastFlightNumber(year int) (int, error) {
+// GetDownloadState retrieve the checkpoint of the given year and scope.
+func (manager *ElasticManager) GetDownloadState(year int, scope string) (*store.DownloadState, error) {
+	key := store.StateKey(year, scope)
 	// Compute the hash of the document to retrieve.
-	hash, err := getStateId(year)
-	log.Debugf("Computed hash for year %d: %s", year, hash)
+	hash, err := getStateId(key)
+	log.Debugf("Computed hash for %s: %s", key, hash)
 	if err != nil {
 		log.Errorf("Unable to compute hash: %v", err)
-		return 0, err
+		return nil, err
 	}
 	// Search with the correct year.
 	res, err := manager.client.Get(stateIndexName, hash)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer res.Body.Close()
-	log.Debugf("GetLastFlightNumber elasticsearch result: %s", res)
+	log.Debugf("GetDownloadState elasticsearch result: %s", res)
 	if res.StatusCode == 200 {
-		var flightNumber LastFlightNumber
-		if err = json.NewDecoder(res.Body).Decode(&flightNumber); err != nil {
-			return 0, err
+		var result DownloadStateResult
+		if err = json.NewDecoder(res.Body).Decode(&result); err != nil {
+			return nil, err
 		}
-		log.Debugf("Extracted flight number: %d", flightNumber.Source.LastFlightNumber)
-		return flightNumber.Source.LastFlightNumber, nil
+		log.Debugf("Extracted flight number: %d", result.Source.LastFlightNumber)
+		return &result.Source, nil
 	}
 	// Read the content of the body before closing.
 	if _, err = io.Copy(io.Discard, res.Body); err != nil {
-		return 0, err
+		return nil, err
+	}
+	if res.StatusCode != 404 {
+		return nil, fmt.Errorf("error getting download state of %s: %s", key, res.Status())
 	}
 	log.Warningf("Unable to get last flight number, set to 0.")
-	return 0, nil
+	return &store.DownloadState{Year: year, Scope: scope}, nil
 }
 
 // InsertFlight insert a single flight.
+//
+// The flight is first looked up in all the indices of the alias, the create only detecting the flights
+// with the same id in the write index of its partition, e.g. not in the indices before a rollover.
 func (manager *ElasticManager) InsertFlight(flight *parser.Flight) error {
-	res, err := manager.client.Index(
-		manager.indexName,
+	id, err := getFlightId(flight.Url)
+	if err != nil {
+		return err
+	}
+	exists, err := manager.FlightExists(flight.Url)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return store.ErrFlightExists
+	}
+	alias, err := manager.writeAlias(context.Background(), flight)
+	if err != nil {
+		return err
+	}
+	res, err := manager.client.Create(
+		alias,
+		id,
 		esutil.NewJSONReader(flight),
 	)
 	if err != nil {
 		return err
 	}
+	defer res.Body.Close()
 	log.Debugf("InsertFlight elasticsearch result: %s", res)
+	if res.StatusCode == 409 {
+		return store.ErrFlightExists
+	}
+	if res.IsError() {
+		return fmt.Errorf("error indexing flight %s: %s", flight.Url, res.String())
+	}
 	return nil
 }
 
-// SetLastFlightNumber save the last processed flight number.
-func (manager *ElasticManager) SetLastFlightNumber(year int, flightNumber int) error {
+// InsertFlights insert a batch of flights using the bulk API.
+//
+// The flights already stored in any index of the alias are reported as duplicates, looked up with a single
+// search before the bulk request. The create actions then only detect the duplicates in the write indices,
+// e.g. the flights inserted concurrently.
+// Each flight failing to be indexed is logged and reported in the result.
+func (manager *ElasticManager) InsertFlights(flights []*parser.Flight) (store.InsertResult, error) {
+	var (
+		result   store.InsertResult
+		mutex    sync.Mutex
+		flushErr error
+	)
+	handled := make(map[*parser.Flight]bool, len(flights))
+	// The flights rejected or already stored are merged once the indexer is closed, its callbacks
+	// running concurrently on the worker.
+	var (
+		rejected   []store.ItemError
+		duplicates []*parser.Flight
+	)
+	ids := make(map[*parser.Flight]string, len(flights))
+	var values []string
+	for _, flight := range flights {
+		id, err := getFlightId(flight.Url)
+		if err != nil {
+			rejected = append(rejected, store.ItemError{Flight: flight, Err: err})
+			continue
+		}
+		ids[flight] = id
+		values = append(values, id)
+	}
+	existing, err := manager.existingIds(context.Background(), values)
+	if err != nil {
+		return result, err
+	}
+	indexer, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
+		Client:        manager.client,
+		Index:         manager.indexName,
+		NumWorkers:    1,
+		FlushBytes:    manager.bulk.FlushBytes,
+		FlushInterval: manager.bulk.FlushInterval,
+		OnError: func(ctx context.Context, err error) {
+			log.Errorf("Bulk indexer error: %v", err)
+			mutex.Lock()
+			defer mutex.Unlock()
+			flushErr = err
+		},
+	})
+	if err != nil {
+		return result, err
+	}
+	for _, flight := range flights {
+		flight := flight
+		id, ok := ids[flight]
+		if !ok {
+			continue
+		}
+		if existing[id] {
+			duplicates = append(duplicates, flight)
+			continue
+		}
+		body, err := json.Marshal(flight)
+		if err != nil {
+			rejected = append(rejected, store.ItemError{Flight: flight, Err: err})
+			continue
+		}
+		alias, err := manager.writeAlias(context.Background(), flight)
+		if err != nil {
+			rejected = append(rejected, store.ItemError{Flight: flight, Err: err})
+			continue
+		}
+		err = indexer.Add(context.Background(), esutil.BulkIndexerItem{
+			Action:     "create",
+			Index:      alias,
+			DocumentID: id,
+			Body:       bytes.NewReader(body),
+			OnSuccess: func(ctx context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				handled[flight] = true
+				result.Inserted = append(result.Inserted, flight)
+			},
+			OnFailure: func(ctx context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem, err error) {
+				if err == nil && res.Status == 409 {
+					mutex.Lock()
+					defer mutex.Unlock()
+					handled[flight] = true
+					result.Duplicates = append(result.Duplicates, flight)
+					return
+				}
+				if err == nil {
+					err = fmt.Errorf("status %d: %s: %s", res.Status, res.Error.Type, res.Error.Reason)
+				}
+				log.Errorf("Error indexing flight %s: %v", flight.Url, err)
+				mutex.Lock()
+				defer mutex.Unlock()
+				handled[flight] = true
+				result.Failed = append(result.Failed, store.ItemError{Flight: flight, Err: err})
+			},
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	if err = indexer.Close(context.Background()); err != nil {
+		return result, err
+	}
+	log.Debugf("InsertFlights bulk stats: %+v", indexer.Stats())
+	for _, flight := range duplicates {
+		handled[flight] = true
+		result.Duplicates = append(result.Duplicates, flight)
+	}
+	for _, item := range rejected {
+		handled[item.Flight] = true
+		result.Failed = append(result.Failed, item)
+	}
+	if flushErr != nil {
+		if len(result.Inserted) == 0 {
+			return result, flushErr
+		}
+		// Flights of a failed bulk request do not get a response of their own.
+		for _, flight := range flights {
+			if !handled[flight] {
+				result.Failed = append(result.Failed, store.ItemError{Flight: flight, Err: flushErr})
+			}
+		}
+	}
+	return result, nil
+}
+
+// SetDownloadState save the checkpoint of a year and scope.
+func (manager *ElasticManager) SetDownloadState(state *store.DownloadState) error {
 	// Compute the hash of the document to save.
-	hash, err := getStateId(year)
-	log.Debugf("Computed hash for year %d: %s", year, hash)
+	hash, err := getStateId(state.Key())
+	log.Debugf("Computed hash for %s: %s", state.Key(), hash)
 	if err != nil {
 		log.Errorf("Unable to compute hash: %v", err)
 		return err
 	}
-	update := fmt.Sprintf(`{"year": %d, "last_flight_number": %d}`, year, flightNumber)
 
 	res, err := manager.client.Index(
 		stateIndexName,
-		strings.NewReader(update),
+		esutil.NewJSONReader(state),
 		manager.client.Index.WithDocumentID(hash),
 		manager.client.Index.WithRefresh("true"),
 	)
 	if err != nil {
 		return err
 	}
-	log.Debugf("SetLastFlightNumber elasticsearch result: %s", res)
+	defer res.Body.Close()
+	log.Debugf("SetDownloadState elasticsearch result: %s", res)
+	if res.IsError() {
+		return fmt.Errorf("error saving download state of %s: %s", state.Key(), res.String())
+	}
+	return nil
+}
 
+// Close releases the resources of the manager.
+//
+// The HTTP client of ElasticSearch does not hold any resource, this is a no-op.
+func (manager *ElasticManager) Close() error {
 	return nil
 }
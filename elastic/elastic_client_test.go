@@ -0,0 +1,97 @@
+package elastic
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fahy.xyz/xcontestextractor/parser"
+)
+
+// newBulkServer answers the searches with the existing ids and each created document with the status of its action,
+// the created ids being recorded.
+func newBulkServer(existing []string, created *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/_search") {
+			var hits []string
+			for _, id := range existing {
+				hits = append(hits, fmt.Sprintf(`{"_index": "flight-2021-000001", "_id": %q}`, id))
+			}
+			_, _ = fmt.Fprintf(w, `{"hits": {"total": {"value": %d}, "hits": [%s]}}`, len(hits), strings.Join(hits, ","))
+			return
+		}
+		var items []string
+		scanner := bufio.NewScanner(r.Body)
+		for line := 0; scanner.Scan(); line++ {
+			if line%2 == 0 {
+				var action map[string]struct {
+					Id string `json:"_id"`
+				}
+				_ = json.Unmarshal(scanner.Bytes(), &action)
+				*created = append(*created, action["create"].Id)
+				items = append(items, `{"create": {"_index": "flight", "status": 201}}`)
+			}
+		}
+		_, _ = fmt.Fprintf(w, `{"errors": false, "items": [%s]}`, strings.Join(items, ","))
+	}))
+}
+
+func TestInsertFlights(t *testing.T) {
+	// The bulk requests are flushed after each flight.
+	var created []string
+	server := newBulkServer(nil, &created)
+	defer server.Close()
+	manager, err := NewElasticManager(server.URL, "", "", "flight", BulkConfig{FlushBytes: 1}, PartitionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var flights []*parser.Flight
+	for i := 0; i < 20; i++ {
+		url := fmt.Sprintf("https://www.xcontest.org/world/en/flights/detail:pilot%d/1.5.2021/10:00", i)
+		if i%2 == 1 {
+			url = "https://www.xcontest.org/invalid"
+		}
+		flights = append(flights, &parser.Flight{Url: url})
+	}
+	result, err := manager.InsertFlights(flights)
+	if err != nil {
+		t.Fatalf("Error inserting the flights: %v", err)
+	}
+	if len(result.Inserted) != 10 || len(result.Failed) != 10 || len(result.Duplicates) != 0 {
+		t.Errorf("Wrong result: %d inserted, %d failed, %d duplicates", len(result.Inserted), len(result.Failed), len(result.Duplicates))
+	}
+}
+
+func TestInsertFlightsExisting(t *testing.T) {
+	existing := &parser.Flight{Url: "https://www.xcontest.org/world/en/flights/detail:pilot1/1.5.2021/10:00"}
+	flight := &parser.Flight{Url: "https://www.xcontest.org/world/en/flights/detail:pilot2/1.5.2021/10:00"}
+	existingId, _ := getFlightId(existing.Url)
+	flightId, _ := getFlightId(flight.Url)
+	// The existing flight is in a backing index which is no longer the write index.
+	var created []string
+	server := newBulkServer([]string{existingId}, &created)
+	defer server.Close()
+	manager, err := NewElasticManager(server.URL, "", "", "flight", BulkConfig{}, PartitionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := manager.InsertFlights([]*parser.Flight{existing, flight})
+	if err != nil {
+		t.Fatalf("Error inserting the flights: %v", err)
+	}
+	if len(result.Duplicates) != 1 || result.Duplicates[0] != existing || len(result.Inserted) != 1 || result.Inserted[0] != flight {
+		t.Errorf("Wrong result: %d inserted, %d failed, %d duplicates", len(result.Inserted), len(result.Failed), len(result.Duplicates))
+	}
+	// The existing flight is not created again.
+	if len(created) != 1 || created[0] != flightId {
+		t.Errorf("Wrong created flights: %v", created)
+	}
+}
@@ -0,0 +1,267 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"fahy.xyz/xcontestextractor/parser"
+)
+
+// mappingProperty is a field of the mapping of an index.
+type mappingProperty struct {
+	Type       string                     `json:"type,omitempty"`
+	Properties map[string]mappingProperty `json:"properties,omitempty"`
+}
+
+// typeName returns the type of the property, `object` for the objects without explicit type.
+func (property mappingProperty) typeName() string {
+	if property.Type == "" {
+		return "object"
+	}
+	return property.Type
+}
+
+type componentTemplate struct {
+	Template struct {
+		Mappings struct {
+			Properties map[string]mappingProperty `json:"properties"`
+		} `json:"mappings"`
+	} `json:"template"`
+}
+
+type indexMapping struct {
+	Mappings struct {
+		Properties map[string]mappingProperty `json:"properties"`
+	} `json:"mappings"`
+}
+
+type aliasResult struct {
+	Aliases map[string]struct {
+		IsWriteIndex *bool `json:"is_write_index"`
+	} `json:"aliases"`
+}
+
+// Types of the mapping able to hold the values of each kind of Go field.
+var compatibleTypes = map[reflect.Kind][]string{
+	reflect.String:  {"keyword", "text", "date", "wildcard"},
+	reflect.Int:     {"long", "integer", "date"},
+	reflect.Int64:   {"long", "integer", "date"},
+	reflect.Float64: {"float", "double", "half_float", "scaled_float"},
+	reflect.Bool:    {"boolean"},
+}
+
+// Types of the mapping of the structs which are not objects.
+var structTypes = map[reflect.Type]string{
+	reflect.TypeOf(parser.GeoPoint{}): "geo_point",
+}
+
+// flightProperties returns the properties of the embedded mapping of the flights.
+func flightProperties() (map[string]mappingProperty, error) {
+	content, err := setupFiles.ReadFile("setup/flight-mappings.json")
+	if err != nil {
+		return nil, err
+	}
+	var template componentTemplate
+	if err = json.Unmarshal(content, &template); err != nil {
+		return nil, fmt.Errorf("invalid mapping of the flights: %w", err)
+	}
+	return template.Template.Mappings.Properties, nil
+}
+
+// jsonFields returns the fields of a struct by JSON name.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// compareMapping returns the fields of the type which are not mapped or mapped with an incompatible type,
+// and the properties of the mapping which are not fields of the type.
+func compareMapping(properties map[string]mappingProperty, t reflect.Type, prefix string) (mismatches []string, unknown []string) {
+	fields := jsonFields(t)
+	for name, fieldType := range fields {
+		path := prefix + name
+		property, ok := properties[name]
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("%s is not mapped", path))
+			continue
+		}
+		for fieldType.Kind() == reflect.Pointer || fieldType.Kind() == reflect.Slice {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			if expected, ok := structTypes[fieldType]; ok {
+				if property.Type != expected {
+					mismatches = append(mismatches, fmt.Sprintf("%s is mapped as %s instead of %s", path, property.typeName(), expected))
+				}
+				continue
+			}
+			if property.Type != "" && property.Type != "object" && property.Type != "nested" {
+				mismatches = append(mismatches, fmt.Sprintf("%s is mapped as %s instead of an object", path, property.Type))
+				continue
+			}
+			subMismatches, subUnknown := compareMapping(property.Properties, fieldType, path+".")
+			mismatches = append(mismatches, subMismatches...)
+			unknown = append(unknown, subUnknown...)
+			continue
+		}
+		if !contains(compatibleTypes[fieldType.Kind()], property.Type) {
+			mismatches = append(mismatches, fmt.Sprintf("%s is mapped as %s, incompatible with %s", path, property.Type, fieldType))
+		}
+	}
+	for name := range properties {
+		if _, ok := fields[name]; !ok {
+			unknown = append(unknown, prefix+name)
+		}
+	}
+	sort.Strings(mismatches)
+	sort.Strings(unknown)
+	return mismatches, unknown
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// writeIndices returns the indices written through the alias of the flights and the aliases of the partitions.
+func (manager *ElasticManager) writeIndices(ctx context.Context) ([]string, error) {
+	groups, err := manager.indexGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var indices []string
+	for _, group := range groups {
+		if group.WriteIndex != "" {
+			indices = append(indices, group.WriteIndex)
+		}
+	}
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("no write index for %s", manager.indexName)
+	}
+	return indices, nil
+}
+
+// getMappings returns the mappings of the indices by name.
+func (manager *ElasticManager) getMappings(ctx context.Context, indices []string) (map[string]indexMapping, error) {
+	res, err := manager.client.Indices.GetMapping(
+		manager.client.Indices.GetMapping.WithContext(ctx),
+		manager.client.Indices.GetMapping.WithIndex(indices...),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var mappings map[string]indexMapping
+	if err = decodeResponse(res, &mappings); err != nil {
+		return nil, fmt.Errorf("error getting the mapping of %s: %w", strings.Join(indices, ","), err)
+	}
+	return mappings, nil
+}
+
+// putFlightMapping adds the fields of the embedded mapping which are missing from the write indices of the flights.
+//
+// The mapping of an index can only be extended: the fields added to the template since the creation of the
+// index are added, while the existing fields are kept, a conflicting type being reported by CheckMapping.
+func (manager *ElasticManager) putFlightMapping(ctx context.Context) error {
+	indices, err := manager.writeIndices(ctx)
+	if err != nil {
+		return err
+	}
+	content, err := setupFiles.ReadFile("setup/flight-mappings.json")
+	if err != nil {
+		return err
+	}
+	// The raw properties are sent to keep the parameters of the fields, e.g. the formats of the dates.
+	var template struct {
+		Template struct {
+			Mappings struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"mappings"`
+		} `json:"template"`
+	}
+	if err = json.Unmarshal(content, &template); err != nil {
+		return fmt.Errorf("invalid mapping of the flights: %w", err)
+	}
+	mappings, err := manager.getMappings(ctx, indices)
+	if err != nil {
+		return err
+	}
+	for _, index := range indices {
+		missing := map[string]json.RawMessage{}
+		for name, property := range template.Template.Mappings.Properties {
+			if _, ok := mappings[index].Mappings.Properties[name]; !ok {
+				missing[name] = property
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		body, err := json.Marshal(map[string]interface{}{"properties": missing})
+		if err != nil {
+			return err
+		}
+		res, err := manager.client.Indices.PutMapping([]string{index}, bytes.NewReader(body),
+			manager.client.Indices.PutMapping.WithContext(ctx))
+		if err = checkSetupResponse(index+" mapping", res, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckMapping returns an error if the mapping of a write index of the flights does not match the fields of
+// parser.Flight, e.g. a field which is not mapped or mapped with an incompatible type.
+//
+// The mapped fields which are not in parser.Flight, e.g. the fields of former versions, are only logged.
+func (manager *ElasticManager) CheckMapping(ctx context.Context) error {
+	indices, err := manager.writeIndices(ctx)
+	if err != nil {
+		return err
+	}
+	mappings, err := manager.getMappings(ctx, indices)
+	if err != nil {
+		return err
+	}
+	for _, index := range indices {
+		mismatches, unknown := compareMapping(mappings[index].Mappings.Properties, reflect.TypeOf(parser.Flight{}), "")
+		if len(unknown) > 0 {
+			log.Warningf("Fields of index %s unknown to the flights: %s", index, strings.Join(unknown, ", "))
+		}
+		if len(mismatches) > 0 {
+			return fmt.Errorf("mapping of index %s does not match the flights: %s", index, strings.Join(mismatches, "; "))
+		}
+	}
+	return nil
+}
+
+// EnsureIndices sets up the templates, the lifecycle policy and the indices, then checks the schema version and
+// the mapping of the flights. A schema with pending migrations is returned as ErrSchemaBehind before checking the
+// mapping, since the migrations change it.
+func (manager *ElasticManager) EnsureIndices(ctx context.Context, maxSize string) error {
+	if err := manager.SetupIndices(ctx, maxSize); err != nil {
+		return err
+	}
+	if err := manager.CheckSchemaVersion(ctx); err != nil {
+		return err
+	}
+	return manager.CheckMapping(ctx)
+}
@@ -0,0 +1,94 @@
+package elastic
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"fahy.xyz/xcontestextractor/parser"
+)
+
+// The embedded mapping must map exactly the JSON fields of parser.Flight.
+func TestFlightMappingMatchesFlight(t *testing.T) {
+	properties, err := flightProperties()
+	if err != nil {
+		t.Fatalf("Error reading the mapping: %v", err)
+	}
+	mismatches, unknown := compareMapping(properties, reflect.TypeOf(parser.Flight{}), "")
+	if len(mismatches) > 0 {
+		t.Errorf("Fields of the flights not matching the mapping: %v", mismatches)
+	}
+	if len(unknown) > 0 {
+		t.Errorf("Mapped fields which are not in the flights: %v", unknown)
+	}
+}
+
+func TestCompareMapping(t *testing.T) {
+	properties, err := flightProperties()
+	if err != nil {
+		t.Fatalf("Error reading the mapping: %v", err)
+	}
+	// Former mapping, with the duration under another name and a distance as text.
+	delete(properties, "flight_duration")
+	properties["flight_time"] = mappingProperty{Type: "keyword"}
+	properties["distance"] = mappingProperty{Type: "text"}
+	turnpoints := properties["turnpoints"]
+	delete(turnpoints.Properties, "latitude")
+	properties["take_off_location"] = mappingProperty{Properties: map[string]mappingProperty{
+		"lat": {Type: "float"}, "lon": {Type: "float"},
+	}}
+
+	mismatches, unknown := compareMapping(properties, reflect.TypeOf(parser.Flight{}), "")
+	expected := []string{
+		"distance is mapped as text, incompatible with float64",
+		"flight_duration is not mapped",
+		"take_off_location is mapped as object instead of geo_point",
+		"turnpoints.latitude is not mapped",
+	}
+	if !reflect.DeepEqual(mismatches, expected) {
+		t.Errorf("Wrong mismatches: %q", mismatches)
+	}
+	if !reflect.DeepEqual(unknown, []string{"flight_time"}) {
+		t.Errorf("Wrong unknown fields: %q", unknown)
+	}
+}
+
+func TestPutFlightMapping(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /flight/_alias":
+			_, _ = io.WriteString(w, `{"flight-000001": {"aliases": {"flight": {"is_write_index": true}}}}`)
+		case "GET /flight-000001/_mapping":
+			// Index created before the mapping of the duration, which was then mapped dynamically.
+			_, _ = io.WriteString(w, `{"flight-000001": {"mappings": {"properties": {
+				"flight_date": {"type": "date"}, "flight_duration": {"type": "text"}}}}}`)
+		case "PUT /flight-000001/_mapping":
+			content, _ := io.ReadAll(r.Body)
+			body = string(content)
+			_, _ = io.WriteString(w, `{"acknowledged": true}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	manager, err := NewElasticManager(server.URL, "", "", "flight", BulkConfig{}, PartitionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = manager.putFlightMapping(context.Background()); err != nil {
+		t.Fatalf("Error putting the mapping: %v", err)
+	}
+	// Only the missing fields are added, the conflicting type being left to the migrations.
+	if !strings.Contains(body, `"pilot_handle":{"type":"keyword"}`) || strings.Contains(body, `"flight_duration":`) ||
+		strings.Contains(body, `"flight_date":`) {
+		t.Errorf("Wrong mapping: %s", body)
+	}
+}
@@ -0,0 +1,388 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+const schemaIndexName = "schema-version"
+
+// Interval between two checks of a reindex task.
+var taskPollInterval = 5 * time.Second
+
+// ErrSchemaBehind is returned when the flights index has not been migrated to the version of the binary.
+var ErrSchemaBehind = errors.New("schema of the flights is behind")
+
+// Migration is a change of the flights index applied by reindexing the flights into a new index,
+// e.g. a field whose mapping changes.
+type Migration struct {
+	Version     int
+	Description string
+	// Script is an optional painless script transforming each flight during the reindex.
+	Script string
+}
+
+// Migrations are the changes of the flights index, in order. The first one is the mapping of the indices created
+// before the migrations, it is never applied.
+//
+// A new migration is added at the end with the next version, after updating the embedded mapping.
+var Migrations = []Migration{
+	{Version: 1, Description: "Initial mapping of the flights"},
+	{
+		Version:     2,
+		Description: "Map flight_duration as a keyword instead of a dynamic text and drop the unused flight_time",
+		Script:      "ctx._source.remove('flight_time')",
+	},
+}
+
+// LatestSchemaVersion returns the version of the flights index expected by the binary.
+func LatestSchemaVersion() int {
+	return Migrations[len(Migrations)-1].Version
+}
+
+// SchemaVersion is the document recording the version of the flights index.
+type SchemaVersion struct {
+	Version int `json:"version"`
+	// Indices behind the alias of the flights by write alias when the version was set.
+	Groups []IndexGroup `json:"groups,omitempty"`
+	// Version before the last migration, restored by a rollback.
+	Previous  *SchemaVersion `json:"previous,omitempty"`
+	UpdatedAt int64          `json:"updated_at"`
+}
+
+// MigrationPlan describes the migrations to apply to the flights index.
+type MigrationPlan struct {
+	From    int
+	To      int
+	Pending []Migration
+	// Indices behind the alias of the flights before the migrations, by write alias.
+	Groups []IndexGroup
+}
+
+type schemaVersionResult struct {
+	Source SchemaVersion `json:"_source"`
+}
+
+type taskResult struct {
+	Task string `json:"task"`
+}
+
+type taskStatus struct {
+	Completed bool            `json:"completed"`
+	Error     json.RawMessage `json:"error"`
+	Response  struct {
+		Total    int64             `json:"total"`
+		Created  int64             `json:"created"`
+		Failures []json.RawMessage `json:"failures"`
+	} `json:"response"`
+}
+
+// pendingMigrations returns the migrations following the version.
+func pendingMigrations(version int) []Migration {
+	var pending []Migration
+	for _, migration := range Migrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// migrationIndex returns the name of the first index of a migration of a group, rolled over like the other ones.
+func migrationIndex(alias string, version int) string {
+	return fmt.Sprintf("%s-v%d-000001", alias, version)
+}
+
+// aliasActions returns the actions moving the write alias of a group and the alias of the flights from the
+// indices of a group to the ones of another group with the same write alias.
+func aliasActions(alias string, from IndexGroup, to IndexGroup) []interface{} {
+	var actions []interface{}
+	for _, index := range from.Indices {
+		actions = append(actions, map[string]interface{}{"remove": map[string]interface{}{"index": index, "alias": alias}})
+		if from.Alias != alias {
+			actions = append(actions, map[string]interface{}{"remove": map[string]interface{}{"index": index, "alias": from.Alias}})
+		}
+	}
+	for _, index := range to.Indices {
+		if to.Alias != alias {
+			// The indices of the partitions are only searched through the alias of the flights.
+			actions = append(actions, map[string]interface{}{"add": map[string]interface{}{"index": index, "alias": alias}})
+		}
+		actions = append(actions, map[string]interface{}{"add": map[string]interface{}{
+			"index":          index,
+			"alias":          to.Alias,
+			"is_write_index": index == to.WriteIndex,
+		}})
+	}
+	return actions
+}
+
+// GetSchemaVersion returns the version of the flights index, the first one if it has never been migrated.
+func (manager *ElasticManager) GetSchemaVersion(ctx context.Context) (*SchemaVersion, error) {
+	res, err := manager.client.Get(schemaIndexName, manager.indexName, manager.client.Get.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == 404 {
+		// Read the content of the body before closing.
+		_, _ = io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		return &SchemaVersion{Version: Migrations[0].Version}, nil
+	}
+	var result schemaVersionResult
+	if err = decodeResponse(res, &result); err != nil {
+		return nil, fmt.Errorf("error getting the schema version: %w", err)
+	}
+	return &result.Source, nil
+}
+
+// putSchemaVersion records the version of the flights index.
+func (manager *ElasticManager) putSchemaVersion(ctx context.Context, version *SchemaVersion) error {
+	version.UpdatedAt = time.Now().UnixMilli()
+	body, err := json.Marshal(version)
+	if err != nil {
+		return err
+	}
+	res, err := manager.client.Index(schemaIndexName, bytes.NewReader(body),
+		manager.client.Index.WithContext(ctx),
+		manager.client.Index.WithDocumentID(manager.indexName),
+		manager.client.Index.WithRefresh("true"),
+	)
+	if err != nil {
+		return err
+	}
+	var result struct{}
+	if err = decodeResponse(res, &result); err != nil {
+		return fmt.Errorf("error saving the schema version: %w", err)
+	}
+	return nil
+}
+
+// CheckSchemaVersion returns ErrSchemaBehind if the flights index has pending migrations.
+func (manager *ElasticManager) CheckSchemaVersion(ctx context.Context) error {
+	version, err := manager.GetSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	latest := LatestSchemaVersion()
+	if version.Version < latest {
+		return fmt.Errorf("%w: version %d instead of %d, the index must be migrated", ErrSchemaBehind, version.Version, latest)
+	}
+	if version.Version > latest {
+		log.Warningf("Schema version %d of the flights is newer than %d", version.Version, latest)
+	}
+	return nil
+}
+
+// PlanMigrations returns the migrations to apply to the flights index.
+func (manager *ElasticManager) PlanMigrations(ctx context.Context) (*MigrationPlan, error) {
+	version, err := manager.GetSchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := manager.indexGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan := &MigrationPlan{
+		From:    version.Version,
+		To:      version.Version,
+		Pending: pendingMigrations(version.Version),
+		Groups:  groups,
+	}
+	if len(plan.Pending) > 0 {
+		plan.To = plan.Pending[len(plan.Pending)-1].Version
+	}
+	return plan, nil
+}
+
+// Migrate applies the pending migrations in order. Each one reindexes the flights of each write alias, the one of
+// the flights or the one of a partition, into a new index, then moves the aliases onto it. The former indices are
+// kept to allow a rollback.
+//
+// The templates must be up-to-date, see SetupIndices, and nothing must write flights during the migration.
+// With dryRun, the plan is only returned.
+func (manager *ElasticManager) Migrate(ctx context.Context, dryRun bool) (*MigrationPlan, error) {
+	plan, err := manager.PlanMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun || len(plan.Pending) == 0 {
+		return plan, nil
+	}
+	for _, group := range plan.Groups {
+		if group.WriteIndex == "" {
+			return nil, fmt.Errorf("no write index for %s", group.Alias)
+		}
+	}
+	previous := &SchemaVersion{Version: plan.From, Groups: plan.Groups}
+	groups := plan.Groups
+	for _, migration := range plan.Pending {
+		log.Infof("Migrating %s to version %d: %s", manager.indexName, migration.Version, migration.Description)
+		migrated := make([]IndexGroup, len(groups))
+		for i, group := range groups {
+			target := migrationIndex(group.Alias, migration.Version)
+			log.Infof("Reindexing %v into %s", group.Indices, target)
+			if err = manager.createMigrationIndex(ctx, group.Alias, target); err != nil {
+				return nil, err
+			}
+			if err = manager.reindex(ctx, group.Indices, target, migration.Script); err != nil {
+				return nil, fmt.Errorf("error migrating %s to version %d: %w", group.Alias, migration.Version, err)
+			}
+			migrated[i] = IndexGroup{Alias: group.Alias, Indices: []string{target}, WriteIndex: target}
+		}
+		// All the aliases are moved at once, the searches never see a partially migrated index.
+		var actions []interface{}
+		for i := range groups {
+			actions = append(actions, aliasActions(manager.indexName, groups[i], migrated[i])...)
+		}
+		if err = manager.updateAliases(ctx, actions); err != nil {
+			return nil, err
+		}
+		groups = migrated
+		version := &SchemaVersion{Version: migration.Version, Groups: groups, Previous: previous}
+		if err = manager.putSchemaVersion(ctx, version); err != nil {
+			return nil, err
+		}
+		log.Infof("Index %s migrated to version %d", manager.indexName, migration.Version)
+	}
+	return plan, nil
+}
+
+// Rollback moves the aliases back onto the indices preceding the last migration and restores the version.
+// The flights written since the migration are not in these indices, while the partitions created since are kept.
+//
+// With dryRun, the version which would be restored is only returned.
+func (manager *ElasticManager) Rollback(ctx context.Context, dryRun bool) (*SchemaVersion, error) {
+	version, err := manager.GetSchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	previous := version.Previous
+	if previous == nil {
+		return nil, fmt.Errorf("no migration to roll back from version %d", version.Version)
+	}
+	if dryRun {
+		return previous, nil
+	}
+	groups, err := manager.indexGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current := map[string]IndexGroup{}
+	for _, group := range groups {
+		current[group.Alias] = group
+	}
+	var actions []interface{}
+	for _, group := range previous.Groups {
+		log.Infof("Rolling back %s from version %d to %d onto %v", group.Alias, version.Version, previous.Version, group.Indices)
+		actions = append(actions, aliasActions(manager.indexName, current[group.Alias], group)...)
+	}
+	if err = manager.updateAliases(ctx, actions); err != nil {
+		return nil, err
+	}
+	if err = manager.putSchemaVersion(ctx, previous); err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+// createMigrationIndex creates the target index of a migration of a group, outside of the aliases.
+func (manager *ElasticManager) createMigrationIndex(ctx context.Context, alias string, name string) error {
+	exists, err := manager.indexExists(ctx, name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("index %s already exists, it must be deleted to run the migration again", name)
+	}
+	options := []func(*esapi.IndicesCreateRequest){manager.client.Indices.Create.WithContext(ctx)}
+	if alias != manager.indexName {
+		body, err := json.Marshal(partitionIndexBody(alias, false))
+		if err != nil {
+			return err
+		}
+		options = append(options, manager.client.Indices.Create.WithBody(bytes.NewReader(body)))
+	}
+	res, err := manager.client.Indices.Create(name, options...)
+	if err = checkSetupResponse(name, res, err); err != nil {
+		return err
+	}
+	// The template adds the index to the alias, it is only added once the flights are reindexed.
+	return manager.updateAliases(ctx, []interface{}{
+		map[string]interface{}{"remove": map[string]interface{}{"index": name, "alias": manager.indexName}},
+	})
+}
+
+// updateAliases applies the actions on the aliases atomically.
+func (manager *ElasticManager) updateAliases(ctx context.Context, actions []interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return err
+	}
+	res, err := manager.client.Indices.UpdateAliases(bytes.NewReader(body),
+		manager.client.Indices.UpdateAliases.WithContext(ctx))
+	return checkSetupResponse(manager.indexName+" aliases", res, err)
+}
+
+// reindex copies the flights of the indices into the target, waiting for the end of the task.
+func (manager *ElasticManager) reindex(ctx context.Context, indices []string, target string, script string) error {
+	request := map[string]interface{}{
+		"source": map[string]interface{}{"index": indices},
+		"dest":   map[string]interface{}{"index": target, "op_type": "create"},
+	}
+	if script != "" {
+		request["script"] = map[string]interface{}{"source": script, "lang": "painless"}
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	res, err := manager.client.Reindex(bytes.NewReader(body),
+		manager.client.Reindex.WithContext(ctx),
+		manager.client.Reindex.WithWaitForCompletion(false),
+		manager.client.Reindex.WithRefresh(true),
+	)
+	if err != nil {
+		return err
+	}
+	var task taskResult
+	if err = decodeResponse(res, &task); err != nil {
+		return fmt.Errorf("error starting the reindex: %w", err)
+	}
+	log.Infof("Reindex task %s started", task.Task)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(taskPollInterval):
+		}
+		res, err = manager.client.Tasks.Get(task.Task, manager.client.Tasks.Get.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		var status taskStatus
+		if err = decodeResponse(res, &status); err != nil {
+			return fmt.Errorf("error getting reindex task %s: %w", task.Task, err)
+		}
+		if !status.Completed {
+			continue
+		}
+		if len(status.Error) > 0 {
+			return fmt.Errorf("reindex task %s failed: %s", task.Task, status.Error)
+		}
+		if len(status.Response.Failures) > 0 {
+			return fmt.Errorf("reindex task %s failed for %d flights, e.g. %s",
+				task.Task, len(status.Response.Failures), status.Response.Failures[0])
+		}
+		log.Infof("%d of %d flights reindexed into %s", status.Response.Created, status.Response.Total, target)
+		return nil
+	}
+}
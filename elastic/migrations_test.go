@@ -0,0 +1,200 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrationsVersions(t *testing.T) {
+	for i, migration := range Migrations {
+		if migration.Version != i+1 {
+			t.Errorf("Migration %d has version %d instead of %d", i, migration.Version, i+1)
+		}
+		if migration.Description == "" {
+			t.Errorf("Migration %d has no description", migration.Version)
+		}
+	}
+	if Migrations[0].Script != "" {
+		t.Error("The initial migration is never applied")
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	if pending := pendingMigrations(LatestSchemaVersion()); len(pending) != 0 {
+		t.Errorf("Unexpected pending migrations: %v", pending)
+	}
+	pending := pendingMigrations(1)
+	if len(pending) != len(Migrations)-1 || pending[0].Version != 2 {
+		t.Errorf("Wrong pending migrations: %v", pending)
+	}
+}
+
+func TestAliasActions(t *testing.T) {
+	from := IndexGroup{Alias: "flight", Indices: []string{"flight-000001", "flight-000002"}, WriteIndex: "flight-000002"}
+	to := IndexGroup{Alias: "flight", Indices: []string{"flight-v2-000001"}, WriteIndex: "flight-v2-000001"}
+	content, err := json.Marshal(aliasActions("flight", from, to))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"remove":{"alias":"flight","index":"flight-000001"}},{"remove":{"alias":"flight","index":"flight-000002"}},` +
+		`{"add":{"alias":"flight","index":"flight-v2-000001","is_write_index":true}}]`
+	if string(content) != expected {
+		t.Errorf("Wrong actions: %s", content)
+	}
+
+	// The indices of a partition are written through its alias and searched through the one of the flights.
+	from = IndexGroup{Alias: "flight-2021", Indices: []string{"flight-2021-000001"}, WriteIndex: "flight-2021-000001"}
+	to = IndexGroup{Alias: "flight-2021", Indices: []string{"flight-2021-v2-000001"}, WriteIndex: "flight-2021-v2-000001"}
+	if content, err = json.Marshal(aliasActions("flight", from, to)); err != nil {
+		t.Fatal(err)
+	}
+	expected = `[{"remove":{"alias":"flight","index":"flight-2021-000001"}},{"remove":{"alias":"flight-2021","index":"flight-2021-000001"}},` +
+		`{"add":{"alias":"flight","index":"flight-2021-v2-000001"}},` +
+		`{"add":{"alias":"flight-2021","index":"flight-2021-v2-000001","is_write_index":true}}]`
+	if string(content) != expected {
+		t.Errorf("Wrong actions: %s", content)
+	}
+}
+
+// fakeCluster answers the requests of a migration, with an index written through the alias of the flights
+// and the one of the 2021 partition.
+type fakeCluster struct {
+	schema   string
+	requests []string
+	bodies   map[string]string
+}
+
+func (c *fakeCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	w.Header().Set("Content-Type", "application/json")
+	request := r.Method + " " + r.URL.Path
+	c.requests = append(c.requests, request)
+	body, _ := io.ReadAll(r.Body)
+	c.bodies[request] = string(body)
+	switch request {
+	case "GET /schema-version/_doc/flight":
+		if c.schema == "" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"found": false}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"found": true, "_source": `+c.schema+`}`)
+	case "PUT /schema-version/_doc/flight":
+		c.schema = string(body)
+		_, _ = io.WriteString(w, `{"result": "updated"}`)
+	case "GET /flight/_alias":
+		_, _ = io.WriteString(w, `{"flight-000001": {"aliases": {"flight": {"is_write_index": true}}},
+			"flight-2021-000001": {"aliases": {"flight": {}, "flight-2021": {"is_write_index": true}}}}`)
+	case "HEAD /flight-v2-000001", "HEAD /flight-2021-v2-000001":
+		w.WriteHeader(http.StatusNotFound)
+	case "PUT /flight-v2-000001", "PUT /flight-2021-v2-000001", "POST /_aliases":
+		_, _ = io.WriteString(w, `{"acknowledged": true}`)
+	case "POST /_reindex":
+		_, _ = io.WriteString(w, `{"task": "node:1"}`)
+	case "GET /_tasks/node:1":
+		_, _ = io.WriteString(w, `{"completed": true, "response": {"total": 3, "created": 3, "failures": []}}`)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"error": "unexpected request"}`)
+	}
+}
+
+func newFakeManager(t *testing.T, cluster *fakeCluster) *ElasticManager {
+	t.Helper()
+	cluster.bodies = map[string]string{}
+	server := httptest.NewServer(cluster)
+	t.Cleanup(server.Close)
+	manager, err := NewElasticManager(server.URL, "", "", "flight", BulkConfig{}, PartitionYearly)
+	if err != nil {
+		t.Fatalf("Error creating the manager: %v", err)
+	}
+	return manager
+}
+
+func TestMigrate(t *testing.T) {
+	previousInterval := taskPollInterval
+	taskPollInterval = time.Millisecond
+	defer func() { taskPollInterval = previousInterval }()
+
+	cluster := &fakeCluster{}
+	manager := newFakeManager(t, cluster)
+	if err := manager.CheckSchemaVersion(context.Background()); !errors.Is(err, ErrSchemaBehind) {
+		t.Fatalf("Expected a behind schema, got %v", err)
+	}
+
+	// The dry run only reads the version and the indices.
+	plan, err := manager.Migrate(context.Background(), true)
+	if err != nil {
+		t.Fatalf("Error planning the migrations: %v", err)
+	}
+	expectedGroups := []IndexGroup{
+		{Alias: "flight", Indices: []string{"flight-000001"}, WriteIndex: "flight-000001"},
+		{Alias: "flight-2021", Indices: []string{"flight-2021-000001"}, WriteIndex: "flight-2021-000001"},
+	}
+	if plan.From != 1 || plan.To != LatestSchemaVersion() || !reflect.DeepEqual(plan.Groups, expectedGroups) {
+		t.Errorf("Wrong plan: %+v", plan)
+	}
+	for _, request := range cluster.requests {
+		if !strings.HasPrefix(request, "GET ") {
+			t.Errorf("Unexpected request during the dry run: %s", request)
+		}
+	}
+
+	cluster.requests = nil
+	if _, err = manager.Migrate(context.Background(), false); err != nil {
+		t.Fatalf("Error migrating: %v", err)
+	}
+	expected := []string{
+		"GET /schema-version/_doc/flight",
+		"GET /flight/_alias",
+		"HEAD /flight-v2-000001",
+		"PUT /flight-v2-000001",
+		"POST /_aliases",
+		"POST /_reindex",
+		"GET /_tasks/node:1",
+		"HEAD /flight-2021-v2-000001",
+		"PUT /flight-2021-v2-000001",
+		"POST /_aliases",
+		"POST /_reindex",
+		"GET /_tasks/node:1",
+		"POST /_aliases",
+		"PUT /schema-version/_doc/flight",
+	}
+	if !reflect.DeepEqual(cluster.requests, expected) {
+		t.Errorf("Wrong requests: %v", cluster.requests)
+	}
+	if !strings.Contains(cluster.bodies["PUT /flight-2021-v2-000001"], `"index.lifecycle.rollover_alias":"flight-2021"`) {
+		t.Errorf("Wrong index of the partition: %s", cluster.bodies["PUT /flight-2021-v2-000001"])
+	}
+	if !strings.Contains(cluster.bodies["POST /_reindex"], `"source":{"index":["flight-2021-000001"]}`) {
+		t.Errorf("Wrong reindex: %s", cluster.bodies["POST /_reindex"])
+	}
+	if err = manager.CheckSchemaVersion(context.Background()); err != nil {
+		t.Errorf("The schema is still behind: %v", err)
+	}
+
+	// The rollback restores the version and the indices before the migration.
+	previous, err := manager.Rollback(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Error rolling back: %v", err)
+	}
+	if previous.Version != 1 || !reflect.DeepEqual(previous.Groups, expectedGroups) {
+		t.Errorf("Wrong version restored: %+v", previous)
+	}
+	aliases := cluster.bodies["POST /_aliases"]
+	if !strings.Contains(aliases, `"alias":"flight","index":"flight-000001","is_write_index":true`) ||
+		!strings.Contains(aliases, `"alias":"flight-2021","index":"flight-2021-000001","is_write_index":true`) {
+		t.Errorf("Wrong aliases: %s", cluster.bodies["POST /_aliases"])
+	}
+	if _, err = manager.Rollback(context.Background(), true); err == nil {
+		t.Error("Expected an error without migration to roll back")
+	}
+}
@@ -0,0 +1,250 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fahy.xyz/xcontestextractor/parser"
+)
+
+// Partitioning selects the backing indices of the flights from their date.
+type Partitioning string
+
+const (
+	// PartitionNone writes all the flights through the alias of the flights, rolled over by size.
+	PartitionNone Partitioning = "none"
+	// PartitionYearly writes the flights through an alias by year, e.g. `flight-2021`.
+	PartitionYearly Partitioning = "yearly"
+	// PartitionMonthly writes the flights through an alias by month, e.g. `flight-2021-05`.
+	PartitionMonthly Partitioning = "monthly"
+)
+
+// ParsePartitioning converts the name of a partitioning, none if empty.
+func ParsePartitioning(value string) (Partitioning, error) {
+	switch partitioning := Partitioning(strings.ToLower(value)); partitioning {
+	case "", PartitionNone:
+		return PartitionNone, nil
+	case PartitionYearly, PartitionMonthly:
+		return partitioning, nil
+	}
+	return "", fmt.Errorf("unknown partitioning %q, expected none, yearly or monthly", value)
+}
+
+// key returns the partition of a flight date in milliseconds, e.g. `2021` or `2021-05`.
+//
+// It is empty without partitioning or if the date is unknown, the flight being written through the alias
+// of the flights.
+func (partitioning Partitioning) key(flightDate int64) string {
+	if flightDate == 0 {
+		return ""
+	}
+	date := time.UnixMilli(flightDate).UTC()
+	switch partitioning {
+	case PartitionYearly:
+		return date.Format("2006")
+	case PartitionMonthly:
+		return date.Format("2006-01")
+	}
+	return ""
+}
+
+// IndexGroup is a set of indices written through an alias, the one of the flights or the one of a partition.
+// All the indices are behind the alias of the flights for the searches.
+type IndexGroup struct {
+	Alias      string   `json:"alias"`
+	Indices    []string `json:"indices"`
+	WriteIndex string   `json:"write_index,omitempty"`
+}
+
+// partitionAlias returns the alias writing the flights of a partition, e.g. `flight-2021`.
+func partitionAlias(alias string, key string) string {
+	return alias + "-" + key
+}
+
+// isPartitionAlias checks if an alias of an index of the flights is the alias of a partition.
+func (manager *ElasticManager) isPartitionAlias(alias string) bool {
+	return strings.HasPrefix(alias, manager.indexName+"-")
+}
+
+// groupIndices groups the indices behind the alias of the flights by write alias, the partitions being sorted
+// after the indices written through the alias of the flights.
+func (manager *ElasticManager) groupIndices(results map[string]aliasResult) []IndexGroup {
+	groups := map[string]*IndexGroup{}
+	group := func(alias string) *IndexGroup {
+		if groups[alias] == nil {
+			groups[alias] = &IndexGroup{Alias: alias}
+		}
+		return groups[alias]
+	}
+	for index, result := range results {
+		alias := manager.indexName
+		for name := range result.Aliases {
+			if manager.isPartitionAlias(name) {
+				alias = name
+			}
+		}
+		g := group(alias)
+		g.Indices = append(g.Indices, index)
+		if writeIndex := result.Aliases[alias].IsWriteIndex; writeIndex != nil && *writeIndex {
+			g.WriteIndex = index
+		}
+	}
+	var sorted []IndexGroup
+	for _, g := range groups {
+		sort.Strings(g.Indices)
+		// A single index is the write index unless it is explicitly disabled.
+		if g.WriteIndex == "" && len(g.Indices) == 1 && results[g.Indices[0]].Aliases[g.Alias].IsWriteIndex == nil {
+			g.WriteIndex = g.Indices[0]
+		}
+		sorted = append(sorted, *g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if (sorted[i].Alias == manager.indexName) != (sorted[j].Alias == manager.indexName) {
+			return sorted[i].Alias == manager.indexName
+		}
+		return sorted[i].Alias < sorted[j].Alias
+	})
+	return sorted
+}
+
+// indexGroups returns the indices behind the alias of the flights, grouped by write alias.
+func (manager *ElasticManager) indexGroups(ctx context.Context) ([]IndexGroup, error) {
+	res, err := manager.client.Indices.GetAlias(
+		manager.client.Indices.GetAlias.WithContext(ctx),
+		manager.client.Indices.GetAlias.WithIndex(manager.indexName),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var results map[string]aliasResult
+	if err = decodeResponse(res, &results); err != nil {
+		return nil, fmt.Errorf("error getting the indices of %s: %w", manager.indexName, err)
+	}
+	return manager.groupIndices(results), nil
+}
+
+// Partitions returns the indices of the partitions, by alias.
+func (manager *ElasticManager) Partitions(ctx context.Context) ([]IndexGroup, error) {
+	groups, err := manager.indexGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var partitions []IndexGroup
+	for _, group := range groups {
+		if group.Alias != manager.indexName {
+			partitions = append(partitions, group)
+		}
+	}
+	return partitions, nil
+}
+
+// writeAlias returns the alias through which the flight is written, creating the index of its partition if needed.
+func (manager *ElasticManager) writeAlias(ctx context.Context, flight *parser.Flight) (string, error) {
+	key := manager.partitioning.key(flight.FlightDate)
+	if key == "" {
+		return manager.indexName, nil
+	}
+	alias := partitionAlias(manager.indexName, key)
+	if _, ok := manager.partitions.Load(alias); ok {
+		return alias, nil
+	}
+	if err := manager.createPartition(ctx, alias); err != nil {
+		return "", err
+	}
+	manager.partitions.Store(alias, true)
+	return alias, nil
+}
+
+// createPartition creates the first index of a partition, rolled over through the alias of the partition.
+//
+// The template adds the index to the alias of the flights, for the searches.
+func (manager *ElasticManager) createPartition(ctx context.Context, alias string) error {
+	exists, err := manager.indexExists(ctx, alias)
+	if err != nil || exists {
+		return err
+	}
+	body, err := json.Marshal(partitionIndexBody(alias, true))
+	if err != nil {
+		return err
+	}
+	index := alias + "-000001"
+	res, err := manager.client.Indices.Create(index,
+		manager.client.Indices.Create.WithContext(ctx),
+		manager.client.Indices.Create.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	// Another extractor may have created it concurrently.
+	if res.IsError() && !strings.Contains(res.String(), "resource_already_exists_exception") {
+		return fmt.Errorf("error creating partition %s: %s", alias, res.String())
+	}
+	log.Infof("Partition %s created with index %s", alias, index)
+	return nil
+}
+
+// partitionIndexBody returns the body creating an index of a partition, with or without its alias.
+func partitionIndexBody(alias string, withAlias bool) map[string]interface{} {
+	body := map[string]interface{}{
+		"settings": map[string]interface{}{"index.lifecycle.rollover_alias": alias},
+	}
+	if withAlias {
+		body["aliases"] = map[string]interface{}{alias: map[string]interface{}{"is_write_index": true}}
+	}
+	return body
+}
+
+// partitionGroup returns the indices of a partition, e.g. `2021`.
+func (manager *ElasticManager) partitionGroup(ctx context.Context, key string) (*IndexGroup, error) {
+	partitions, err := manager.Partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	alias := partitionAlias(manager.indexName, key)
+	for _, partition := range partitions {
+		if partition.Alias == alias {
+			return &partition, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown partition %s", key)
+}
+
+// DeletePartition deletes the indices of a partition, e.g. `2021`, to rebuild it. The partition is created again
+// by the next flight written into it.
+//
+// Nothing must write flights into the partition during the deletion.
+func (manager *ElasticManager) DeletePartition(ctx context.Context, key string) ([]string, error) {
+	partition, err := manager.partitionGroup(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	res, err := manager.client.Indices.Delete(partition.Indices, manager.client.Indices.Delete.WithContext(ctx))
+	if err = checkSetupResponse(partition.Alias, res, err); err != nil {
+		return nil, err
+	}
+	manager.partitions.Delete(partition.Alias)
+	return partition.Indices, nil
+}
+
+// ForceMergePartition merges the segments of the indices of a partition into one, e.g. for a past year.
+func (manager *ElasticManager) ForceMergePartition(ctx context.Context, key string) ([]string, error) {
+	partition, err := manager.partitionGroup(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	res, err := manager.client.Indices.Forcemerge(
+		manager.client.Indices.Forcemerge.WithContext(ctx),
+		manager.client.Indices.Forcemerge.WithIndex(partition.Indices...),
+		manager.client.Indices.Forcemerge.WithMaxNumSegments(1),
+	)
+	if err = checkSetupResponse(partition.Alias, res, err); err != nil {
+		return nil, err
+	}
+	return partition.Indices, nil
+}
@@ -0,0 +1,104 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"fahy.xyz/xcontestextractor/parser"
+)
+
+func TestParsePartitioning(t *testing.T) {
+	for value, expected := range map[string]Partitioning{"": PartitionNone, "none": PartitionNone, "Yearly": PartitionYearly, "monthly": PartitionMonthly} {
+		partitioning, err := ParsePartitioning(value)
+		if err != nil || partitioning != expected {
+			t.Errorf("Wrong partitioning of %q: %s, %v", value, partitioning, err)
+		}
+	}
+	if _, err := ParsePartitioning("weekly"); err == nil {
+		t.Error("Expected an error for an unknown partitioning")
+	}
+}
+
+func TestPartitionKey(t *testing.T) {
+	date := time.Date(2021, 12, 31, 23, 30, 0, 0, time.UTC).UnixMilli()
+	for partitioning, expected := range map[Partitioning]string{PartitionNone: "", PartitionYearly: "2021", PartitionMonthly: "2021-12"} {
+		if key := partitioning.key(date); key != expected {
+			t.Errorf("Wrong key for %s: %q", partitioning, key)
+		}
+	}
+	if key := PartitionYearly.key(0); key != "" {
+		t.Errorf("Unexpected key for an unknown date: %q", key)
+	}
+}
+
+func TestGroupIndices(t *testing.T) {
+	var results map[string]aliasResult
+	content := `{
+		"flight-000001": {"aliases": {"flight": {"is_write_index": false}}},
+		"flight-000002": {"aliases": {"flight": {"is_write_index": true}}},
+		"flight-2022-000001": {"aliases": {"flight": {}, "flight-2022": {"is_write_index": true}}},
+		"flight-2021-000002": {"aliases": {"flight": {}, "flight-2021": {"is_write_index": true}}},
+		"flight-2021-000001": {"aliases": {"flight": {}, "flight-2021": {"is_write_index": false}}}
+	}`
+	if err := json.Unmarshal([]byte(content), &results); err != nil {
+		t.Fatal(err)
+	}
+	manager := &ElasticManager{indexName: "flight"}
+	expected := []IndexGroup{
+		{Alias: "flight", Indices: []string{"flight-000001", "flight-000002"}, WriteIndex: "flight-000002"},
+		{Alias: "flight-2021", Indices: []string{"flight-2021-000001", "flight-2021-000002"}, WriteIndex: "flight-2021-000002"},
+		{Alias: "flight-2022", Indices: []string{"flight-2022-000001"}, WriteIndex: "flight-2022-000001"},
+	}
+	if groups := manager.groupIndices(results); !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Wrong groups: %+v", groups)
+	}
+}
+
+func TestWriteAlias(t *testing.T) {
+	var requests []string
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		case http.MethodPut:
+			content, _ := io.ReadAll(r.Body)
+			body = string(content)
+			_, _ = w.Write([]byte(`{"acknowledged": true}`))
+		}
+	}))
+	defer server.Close()
+	manager, err := NewElasticManager(server.URL, "", "", "flight", BulkConfig{}, PartitionYearly)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flight := &parser.Flight{FlightDate: time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC).UnixMilli()}
+	for i := 0; i < 2; i++ {
+		alias, err := manager.writeAlias(context.Background(), flight)
+		if err != nil || alias != "flight-2021" {
+			t.Fatalf("Wrong alias: %s, %v", alias, err)
+		}
+	}
+	// The partition is only created once.
+	if !reflect.DeepEqual(requests, []string{"HEAD /flight-2021", "PUT /flight-2021-000001"}) {
+		t.Errorf("Wrong requests: %v", requests)
+	}
+	expected := `{"aliases":{"flight-2021":{"is_write_index":true}},"settings":{"index.lifecycle.rollover_alias":"flight-2021"}}`
+	if body != expected {
+		t.Errorf("Wrong body: %s", body)
+	}
+
+	// A flight without date is written through the alias of the flights.
+	if alias, err := manager.writeAlias(context.Background(), &parser.Flight{}); err != nil || alias != "flight" {
+		t.Errorf("Wrong alias: %s, %v", alias, err)
+	}
+}
@@ -0,0 +1,121 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"fahy.xyz/xcontestextractor/parser"
+	"fahy.xyz/xcontestextractor/store"
+	"github.com/elastic/go-elasticsearch/v8/esutil"
+)
+
+const (
+	pilotIndexName = "pilot"
+	// Number of retries when the same pilot is updated concurrently.
+	pilotRetryOnConflict = 5
+)
+
+// addFlightScript updates a pilot with a new flight, the same way as store.Pilot.AddFlight.
+const addFlightScript = `
+def pilot = ctx._source;
+pilot.flight_count += 1;
+pilot.total_distance += params.distance;
+if (params.flight_date < pilot.first_seen) {
+  pilot.first_seen = params.flight_date;
+}
+if (params.flight_date >= pilot.last_seen) {
+  pilot.last_seen = params.flight_date;
+  pilot.full_name = params.full_name;
+}
+if (pilot.countries == null) {
+  pilot.countries = new HashMap();
+}
+int count = pilot.countries.getOrDefault(params.country_code, 0) + 1;
+pilot.countries[params.country_code] = count;
+if (count > pilot.countries.getOrDefault(pilot.home_country, 0)) {
+  pilot.home_country = params.country_code;
+}
+`
+
+type pilotUpdate struct {
+	Script struct {
+		Source string                 `json:"source"`
+		Lang   string                 `json:"lang"`
+		Params map[string]interface{} `json:"params"`
+	} `json:"script"`
+	Upsert *store.Pilot `json:"upsert"`
+}
+
+// newPilotUpdate creates the scripted update of the pilot of a flight, creating the pilot if needed.
+func newPilotUpdate(flight *parser.Flight) pilotUpdate {
+	var update pilotUpdate
+	update.Script.Source = addFlightScript
+	update.Script.Lang = "painless"
+	update.Script.Params = map[string]interface{}{
+		"full_name":    flight.FullName,
+		"country_code": flight.CountryCode,
+		"flight_date":  flight.FlightDate,
+		"distance":     flight.Distance,
+	}
+	update.Upsert = store.NewPilot(flight)
+	update.Upsert.AddFlight(flight)
+	return update
+}
+
+// UpdatePilots adds newly inserted flights to the statistics of their pilots in the pilot index.
+func (manager *ElasticManager) UpdatePilots(flights []*parser.Flight) error {
+	var failed int64
+	indexer, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
+		Client:        manager.client,
+		Index:         pilotIndexName,
+		NumWorkers:    1,
+		FlushBytes:    manager.bulk.FlushBytes,
+		FlushInterval: manager.bulk.FlushInterval,
+		OnError: func(ctx context.Context, err error) {
+			log.Errorf("Bulk indexer error: %v", err)
+			atomic.AddInt64(&failed, 1)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	retries := pilotRetryOnConflict
+	for _, flight := range flights {
+		if flight.PilotHandle == "" {
+			log.Errorf("Error updating the pilot of flight %s: %v", flight.Url, store.ErrNoPilotHandle)
+			atomic.AddInt64(&failed, 1)
+			continue
+		}
+		handle := flight.PilotHandle
+		body, err := json.Marshal(newPilotUpdate(flight))
+		if err != nil {
+			return err
+		}
+		err = indexer.Add(context.Background(), esutil.BulkIndexerItem{
+			Action:          "update",
+			DocumentID:      handle,
+			RetryOnConflict: &retries,
+			Body:            bytes.NewReader(body),
+			OnFailure: func(ctx context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem, err error) {
+				if err == nil {
+					err = fmt.Errorf("status %d: %s: %s", res.Status, res.Error.Type, res.Error.Reason)
+				}
+				log.Errorf("Error updating pilot %s: %v", handle, err)
+				atomic.AddInt64(&failed, 1)
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err = indexer.Close(context.Background()); err != nil {
+		return err
+	}
+	if count := atomic.LoadInt64(&failed); count > 0 {
+		return fmt.Errorf("%d pilots failed to be updated", count)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"fahy.xyz/xcontestextractor/parser"
+	"fahy.xyz/xcontestextractor/store"
+)
+
+// Minimal distances in km of the weekly statistics.
+var weeklyDistances = []int{50, 100, 150, 200}
+
+type flightResults struct {
+	Hits struct {
+		Total struct {
+			Value int64 `json:"value"`
+		} `json:"total"`
+		Hits []searchHit `json:"hits"`
+	} `json:"hits"`
+}
+
+type pilotResult struct {
+	Source store.Pilot `json:"_source"`
+}
+
+type valueAggregation struct {
+	Value *float64 `json:"value"`
+}
+
+type weeklyResults struct {
+	Aggregations struct {
+		Weekly struct {
+			Buckets []struct {
+				Key       int64 `json:"key"`
+				Countries struct {
+					Buckets []struct {
+						Key         string           `json:"key"`
+						DocCount    int64            `json:"doc_count"`
+						DistanceAvg valueAggregation `json:"distance_avg"`
+						DistanceMed valueAggregation `json:"distance_med"`
+						Above50     struct {
+							DocCount int64 `json:"doc_count"`
+						} `json:"count_above_50km"`
+						Above100 struct {
+							DocCount int64 `json:"doc_count"`
+						} `json:"count_above_100km"`
+						Above150 struct {
+							DocCount int64 `json:"doc_count"`
+						} `json:"count_above_150km"`
+						Above200 struct {
+							DocCount int64 `json:"doc_count"`
+						} `json:"count_above_200km"`
+					} `json:"buckets"`
+				} `json:"group_by_country"`
+			} `json:"buckets"`
+		} `json:"statistics_weekly"`
+	} `json:"aggregations"`
+}
+
+// filters converts a query into the filters of a bool query.
+func filters(query store.FlightQuery) []map[string]interface{} {
+	var result []map[string]interface{}
+	term := func(field string, value string) {
+		if value != "" {
+			result = append(result, map[string]interface{}{"term": map[string]interface{}{field: value}})
+		}
+	}
+	term("country_code", query.Country)
+	term("flight_type", query.FlightType)
+	term("pilot_handle", query.PilotHandle)
+	if query.From != 0 || query.To != 0 {
+		dates := map[string]interface{}{}
+		if query.From != 0 {
+			dates["gte"] = query.From
+		}
+		if query.To != 0 {
+			dates["lte"] = query.To
+		}
+		result = append(result, map[string]interface{}{"range": map[string]interface{}{"flight_date": dates}})
+	}
+	if query.MinDistance != 0 || query.MaxDistance != 0 {
+		distances := map[string]interface{}{}
+		if query.MinDistance != 0 {
+			distances["gte"] = query.MinDistance
+		}
+		if query.MaxDistance != 0 {
+			distances["lte"] = query.MaxDistance
+		}
+		result = append(result, map[string]interface{}{"range": map[string]interface{}{"distance": distances}})
+	}
+	return result
+}
+
+// boolQuery returns the query matching the flights of a query.
+func boolQuery(query store.FlightQuery) map[string]interface{} {
+	return map[string]interface{}{"bool": map[string]interface{}{"filter": filters(query)}}
+}
+
+// search runs a search request on the flights and decodes its response.
+func (manager *ElasticManager) search(ctx context.Context, body map[string]interface{}, v interface{}) error {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	log.Debugf("Elasticsearch query: %s", content)
+	res, err := manager.client.Search(
+		manager.client.Search.WithContext(ctx),
+		manager.client.Search.WithIndex(manager.indexName),
+		manager.client.Search.WithBody(bytes.NewReader(content)),
+	)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(res, v)
+}
+
+// SearchFlights returns a page of the flights matching the query, the latest first.
+func (manager *ElasticManager) SearchFlights(ctx context.Context, query store.FlightQuery) (*store.FlightPage, error) {
+	body := map[string]interface{}{
+		"query":            boolQuery(query),
+		"from":             query.Offset,
+		"size":             query.Size,
+		"sort":             []interface{}{map[string]interface{}{"flight_date": "desc"}, map[string]interface{}{"distance": "desc"}},
+		"track_total_hits": true,
+	}
+	var results flightResults
+	if err := manager.search(ctx, body, &results); err != nil {
+		return nil, fmt.Errorf("error searching flights: %w", err)
+	}
+	page := &store.FlightPage{
+		Total:   results.Hits.Total.Value,
+		Offset:  query.Offset,
+		Size:    query.Size,
+		Flights: make([]store.FlightHit, len(results.Hits.Hits)),
+	}
+	for i := range results.Hits.Hits {
+		hit := &results.Hits.Hits[i]
+		page.Flights[i] = store.FlightHit{Id: hit.Id, Flight: &hit.Source}
+	}
+	return page, nil
+}
+
+// GetFlight returns the flight with the given id, store.ErrNotFound if it does not exist.
+func (manager *ElasticManager) GetFlight(ctx context.Context, id string) (*parser.Flight, error) {
+	// An ids query is used instead of a get since the alias can point to multiple indices.
+	body := map[string]interface{}{
+		"query": map[string]interface{}{"ids": map[string]interface{}{"values": []string{id}}},
+		"size":  1,
+	}
+	var results flightResults
+	if err := manager.search(ctx, body, &results); err != nil {
+		return nil, fmt.Errorf("error getting flight %s: %w", id, err)
+	}
+	if len(results.Hits.Hits) == 0 {
+		return nil, store.ErrNotFound
+	}
+	return &results.Hits.Hits[0].Source, nil
+}
+
+// GetPilot returns the pilot with the given handle, store.ErrNotFound if it does not exist.
+func (manager *ElasticManager) GetPilot(ctx context.Context, handle string) (*store.Pilot, error) {
+	res, err := manager.client.Get(pilotIndexName, handle, manager.client.Get.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == 404 {
+		// Read the content of the body before closing.
+		_, _ = io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		return nil, store.ErrNotFound
+	}
+	var result pilotResult
+	if err = decodeResponse(res, &result); err != nil {
+		return nil, fmt.Errorf("error getting pilot %s: %w", handle, err)
+	}
+	return &result.Source, nil
+}
+
+// WeeklyStats computes the statistics of the distances of the flights matching the query by year, week and country.
+//
+// The aggregations are the same as the ones of the weekly statistics script, the median of the distances being
+// their median absolute deviation. The weeks are ISO weeks in the year of the ISO week, e.g. the flights of
+// the 30th of December 2024 are in the week 1 of 2025.
+func (manager *ElasticManager) WeeklyStats(ctx context.Context, query store.FlightQuery) ([]store.WeeklyStats, error) {
+	countryAggregations := map[string]interface{}{
+		"distance_avg": map[string]interface{}{"avg": map[string]interface{}{"field": "distance"}},
+		"distance_med": map[string]interface{}{"median_absolute_deviation": map[string]interface{}{"field": "distance"}},
+	}
+	for _, distance := range weeklyDistances {
+		countryAggregations[fmt.Sprintf("count_above_%dkm", distance)] = map[string]interface{}{
+			"filter": map[string]interface{}{"range": map[string]interface{}{"distance": map[string]interface{}{"gte": distance}}},
+		}
+	}
+	body := map[string]interface{}{
+		"size":  0,
+		"query": boolQuery(query),
+		"aggs": map[string]interface{}{
+			// The weeks are not split by calendar year, a week spanning two years being a single bucket.
+			"statistics_weekly": map[string]interface{}{
+				"date_histogram": map[string]interface{}{"field": "flight_date", "calendar_interval": "week"},
+				"aggs": map[string]interface{}{
+					"group_by_country": map[string]interface{}{
+						// All the countries instead of the 10 first ones.
+						"terms": map[string]interface{}{"field": "country_code", "size": 500},
+						"aggs":  countryAggregations,
+					},
+				},
+			},
+		},
+	}
+	var results weeklyResults
+	if err := manager.search(ctx, body, &results); err != nil {
+		return nil, fmt.Errorf("error computing weekly statistics: %w", err)
+	}
+	return results.toStats(), nil
+}
+
+// toStats flattens the buckets of the aggregations.
+func (results *weeklyResults) toStats() []store.WeeklyStats {
+	var stats []store.WeeklyStats
+	for _, week := range results.Aggregations.Weekly.Buckets {
+		yearNumber, weekNumber := time.UnixMilli(week.Key).UTC().ISOWeek()
+		for _, country := range week.Countries.Buckets {
+			stat := store.WeeklyStats{
+				Year:            yearNumber,
+				Week:            weekNumber,
+				Country:         country.Key,
+				Flights:         country.DocCount,
+				CountAbove50km:  country.Above50.DocCount,
+				CountAbove100km: country.Above100.DocCount,
+				CountAbove150km: country.Above150.DocCount,
+				CountAbove200km: country.Above200.DocCount,
+			}
+			if country.DistanceAvg.Value != nil {
+				stat.DistanceAvg = *country.DistanceAvg.Value
+			}
+			if country.DistanceMed.Value != nil {
+				stat.DistanceMed = *country.DistanceMed.Value
+			}
+			stats = append(stats, stat)
+		}
+	}
+	return stats
+}
@@ -0,0 +1,100 @@
+package elastic
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"fahy.xyz/xcontestextractor/store"
+)
+
+// Response of the aggregations of the weekly statistics, with a week of the year 2021 and the first
+// and last weeks of 2024, the last one being the week 1 of 2025.
+const weeklyResponse = `{
+  "aggregations": {
+    "statistics_weekly": {
+      "buckets": [{
+        "key_as_string": "2021-11-01T00:00:00.000Z",
+        "key": 1635724800000,
+        "doc_count": 3,
+        "group_by_country": {
+          "buckets": [{
+            "key": "CH",
+            "doc_count": 3,
+            "distance_avg": {"value": 40.5},
+            "distance_med": {"value": 5.5},
+            "count_above_50km": {"doc_count": 1},
+            "count_above_100km": {"doc_count": 1},
+            "count_above_150km": {"doc_count": 0},
+            "count_above_200km": {"doc_count": 0}
+          }]
+        }
+      }, {
+        "key_as_string": "2024-01-01T00:00:00.000Z",
+        "key": 1704067200000,
+        "doc_count": 1,
+        "group_by_country": {
+          "buckets": [{
+            "key": "BR",
+            "doc_count": 1,
+            "distance_avg": {"value": 12},
+            "distance_med": {"value": 0},
+            "count_above_50km": {"doc_count": 0},
+            "count_above_100km": {"doc_count": 0},
+            "count_above_150km": {"doc_count": 0},
+            "count_above_200km": {"doc_count": 0}
+          }]
+        }
+      }, {
+        "key_as_string": "2024-12-30T00:00:00.000Z",
+        "key": 1735516800000,
+        "doc_count": 1,
+        "group_by_country": {
+          "buckets": [{
+            "key": "BR",
+            "doc_count": 1,
+            "distance_avg": {"value": 60},
+            "distance_med": {"value": 0},
+            "count_above_50km": {"doc_count": 1},
+            "count_above_100km": {"doc_count": 0},
+            "count_above_150km": {"doc_count": 0},
+            "count_above_200km": {"doc_count": 0}
+          }]
+        }
+      }]
+    }
+  }
+}`
+
+func TestWeeklyResultsToStats(t *testing.T) {
+	var results weeklyResults
+	if err := json.Unmarshal([]byte(weeklyResponse), &results); err != nil {
+		t.Fatalf("Error decoding the response: %v", err)
+	}
+	expected := []store.WeeklyStats{{
+		Year:            2021,
+		Week:            44,
+		Country:         "CH",
+		Flights:         3,
+		DistanceAvg:     40.5,
+		DistanceMed:     5.5,
+		CountAbove50km:  1,
+		CountAbove100km: 1,
+	}, {
+		Year:        2024,
+		Week:        1,
+		Country:     "BR",
+		Flights:     1,
+		DistanceAvg: 12,
+	}, {
+		Year:           2025,
+		Week:           1,
+		Country:        "BR",
+		Flights:        1,
+		DistanceAvg:    60,
+		CountAbove50km: 1,
+	}}
+	if stats := results.toStats(); !reflect.DeepEqual(stats, expected) {
+		t.Errorf("Wrong statistics: %+v", stats)
+	}
+}
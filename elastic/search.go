@@ -0,0 +1,90 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"fahy.xyz/xcontestextractor/parser"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+const (
+	// Duration of the search context between two batches of a scroll.
+	scrollTimeout = time.Minute
+)
+
+type searchHit struct {
+	Index  string        `json:"_index"`
+	Id     string        `json:"_id"`
+	Source parser.Flight `json:"_source"`
+}
+
+type searchResults struct {
+	ScrollId string `json:"_scroll_id"`
+	Hits     struct {
+		Hits []searchHit `json:"hits"`
+	} `json:"hits"`
+}
+
+// decodeResponse checks the status of a response and decodes its body.
+func decodeResponse(res *esapi.Response, v interface{}) error {
+	defer res.Body.Close()
+	if res.IsError() {
+		// Read the content of the body before closing.
+		_, _ = io.Copy(io.Discard, res.Body)
+		return fmt.Errorf("elasticsearch error: %s", res.Status())
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// scroll runs the query on the flights and calls handle with each batch of hits.
+func (manager *ElasticManager) scroll(ctx context.Context, query string, batchSize int, handle func([]searchHit) error) error {
+	res, err := manager.client.Search(
+		manager.client.Search.WithContext(ctx),
+		manager.client.Search.WithIndex(manager.indexName),
+		manager.client.Search.WithBody(strings.NewReader(query)),
+		manager.client.Search.WithSize(batchSize),
+		manager.client.Search.WithScroll(scrollTimeout),
+	)
+	var scrollId string
+	defer func() {
+		if scrollId != "" {
+			manager.clearScroll(scrollId)
+		}
+	}()
+	for {
+		if err != nil {
+			return err
+		}
+		var results searchResults
+		if err = decodeResponse(res, &results); err != nil {
+			return err
+		}
+		scrollId = results.ScrollId
+		if len(results.Hits.Hits) == 0 {
+			return nil
+		}
+		if err = handle(results.Hits.Hits); err != nil {
+			return err
+		}
+		res, err = manager.client.Scroll(
+			manager.client.Scroll.WithContext(ctx),
+			manager.client.Scroll.WithScrollID(scrollId),
+			manager.client.Scroll.WithScroll(scrollTimeout),
+		)
+	}
+}
+
+// clearScroll releases the search context of a scroll.
+func (manager *ElasticManager) clearScroll(scrollId string) {
+	res, err := manager.client.ClearScroll(manager.client.ClearScroll.WithScrollID(scrollId))
+	if err != nil {
+		log.Warningf("Error clearing scroll: %v", err)
+		return
+	}
+	res.Body.Close()
+}
@@ -0,0 +1,187 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+const (
+	flightTemplateName = "flight"
+	// firstFlightIndex is the first index of the flights, rolled over by the lifecycle policy.
+	firstFlightIndex = "flight-000001"
+)
+
+// DefaultMaxIndexSize is the size of the primary shard of the flights index triggering a rollover.
+const DefaultMaxIndexSize = "10GB"
+
+// setupFiles contains the bodies of the requests creating the templates and the indices.
+//
+//go:embed setup/*.json
+var setupFiles embed.FS
+
+// readSetupFile reads a body of the setup, replacing the maximal size of the index.
+func readSetupFile(name string, maxSize string) (io.Reader, error) {
+	content, err := setupFiles.ReadFile("setup/" + name + ".json")
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(bytes.ReplaceAll(content, []byte("${max_size}"), []byte(maxSize))), nil
+}
+
+// checkSetupResponse returns an error if the request of a setup step failed.
+func checkSetupResponse(name string, res *esapi.Response, err error) error {
+	if err != nil {
+		return fmt.Errorf("error setting up %s: %w", name, err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("error setting up %s: %s", name, res.String())
+	}
+	log.Infof("Request for (%s) successfully set", name)
+	return nil
+}
+
+// indexExists checks if an index or an alias exists.
+func (manager *ElasticManager) indexExists(ctx context.Context, name string) (bool, error) {
+	res, err := manager.client.Indices.Exists([]string{name}, manager.client.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case 200:
+		return true, nil
+	case 404:
+		return false, nil
+	}
+	return false, fmt.Errorf("error checking index %s: %s", name, res.Status())
+}
+
+// createIndex creates an index with an optional body, if it does not exist yet.
+func (manager *ElasticManager) createIndex(ctx context.Context, name string, body io.Reader) error {
+	exists, err := manager.indexExists(ctx, name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		log.Infof("Index %s already exists, skipping.", name)
+		return nil
+	}
+	options := []func(*esapi.IndicesCreateRequest){manager.client.Indices.Create.WithContext(ctx)}
+	if body != nil {
+		options = append(options, manager.client.Indices.Create.WithBody(body))
+	}
+	res, err := manager.client.Indices.Create(name, options...)
+	return checkSetupResponse(name, res, err)
+}
+
+// SetupIndices creates the templates, the lifecycle policy and the indices of the flights,
+// the download states and the pilots. It MUST be run before any indexing.
+//
+// It can be run several times, the templates are updated and the existing indices are kept, the new fields
+// of the mapping being added to the write index of the flights. A new flights index is at the latest schema version.
+// The flights index is rolled over when its primary shard reaches maxSize, e.g. `10GB`.
+func (manager *ElasticManager) SetupIndices(ctx context.Context, maxSize string) error {
+	if maxSize == "" {
+		maxSize = DefaultMaxIndexSize
+	}
+	log.Info("Creating index templates...")
+
+	// Flights, with a lifecycle policy rolling over the indices behind the alias.
+	body, err := readSetupFile("flight-mappings", maxSize)
+	if err != nil {
+		return err
+	}
+	res, err := manager.client.Cluster.PutComponentTemplate(flightTemplateName+"-mappings", body,
+		manager.client.Cluster.PutComponentTemplate.WithContext(ctx))
+	if err = checkSetupResponse(flightTemplateName+"-mappings", res, err); err != nil {
+		return err
+	}
+
+	log.Infof("Add lifecycle policy for %s with a maximal size of %s", flightTemplateName, maxSize)
+	if body, err = readSetupFile("flight-ilm-policy", maxSize); err != nil {
+		return err
+	}
+	res, err = manager.client.ILM.PutLifecycle(flightTemplateName+"-ilm-policy",
+		manager.client.ILM.PutLifecycle.WithBody(body),
+		manager.client.ILM.PutLifecycle.WithContext(ctx))
+	if err = checkSetupResponse(flightTemplateName+"-ilm-policy", res, err); err != nil {
+		return err
+	}
+
+	if body, err = readSetupFile("flight-ilm-settings", maxSize); err != nil {
+		return err
+	}
+	res, err = manager.client.Cluster.PutComponentTemplate(flightTemplateName+"-ilm-settings", body,
+		manager.client.Cluster.PutComponentTemplate.WithContext(ctx))
+	if err = checkSetupResponse(flightTemplateName+"-ilm-settings", res, err); err != nil {
+		return err
+	}
+
+	if body, err = readSetupFile("flight-template", maxSize); err != nil {
+		return err
+	}
+	res, err = manager.client.Indices.PutIndexTemplate(flightTemplateName, body,
+		manager.client.Indices.PutIndexTemplate.WithContext(ctx))
+	if err = checkSetupResponse(flightTemplateName, res, err); err != nil {
+		return err
+	}
+
+	// The first index is the write index of the alias, the migrations move the alias onto other indices.
+	if err = manager.createIndex(ctx, schemaIndexName, nil); err != nil {
+		return err
+	}
+	exists, err := manager.indexExists(ctx, manager.indexName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		log.Infof("Alias %s already exists, skipping.", manager.indexName)
+	} else {
+		if body, err = readSetupFile("flight-index", maxSize); err != nil {
+			return err
+		}
+		if err = manager.createIndex(ctx, firstFlightIndex, body); err != nil {
+			return err
+		}
+		// A new index has the latest mapping.
+		version := &SchemaVersion{
+			Version: LatestSchemaVersion(),
+			Groups:  []IndexGroup{{Alias: manager.indexName, Indices: []string{firstFlightIndex}, WriteIndex: firstFlightIndex}},
+		}
+		if err = manager.putSchemaVersion(ctx, version); err != nil {
+			return err
+		}
+	}
+	// The write index may have been created with a former version of the template. A behind schema may conflict
+	// with the template, its migration creates a new index instead.
+	if err = manager.CheckSchemaVersion(ctx); errors.Is(err, ErrSchemaBehind) {
+		log.Warningf("Mapping of %s not updated: %v", manager.indexName, err)
+	} else if err != nil {
+		return err
+	} else if err = manager.putFlightMapping(ctx); err != nil {
+		return err
+	}
+
+	// Download states and pilots, in a single index each.
+	for _, name := range []string{stateIndexName, pilotIndexName} {
+		if body, err = readSetupFile(name+"-template", maxSize); err != nil {
+			return err
+		}
+		res, err = manager.client.Indices.PutIndexTemplate(name, body,
+			manager.client.Indices.PutIndexTemplate.WithContext(ctx))
+		if err = checkSetupResponse(name, res, err); err != nil {
+			return err
+		}
+		if err = manager.createIndex(ctx, name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -8,12 +8,12 @@ require (
 	github.com/chromedp/chromedp v0.8.7
 	github.com/elastic/go-elasticsearch/v8 v8.6.0
 	github.com/jarcoal/httpmock v1.3.0
-	github.com/kelseyhightower/envconfig v1.4.0
-	github.com/mottaquikarim/esquerydsl v0.0.0-20220725035144-d87f6844c615
 	github.com/procyon-projects/chrono v1.1.2
 	github.com/prometheus/client_golang v1.14.0
 	github.com/sqooba/go-common v0.0.0-20230125131914-ef63c1e34f33
+	go.etcd.io/bbolt v1.3.7
 	golang.org/x/net v0.7.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -28,6 +28,7 @@ require (
 	github.com/gobwas/ws v1.1.0 // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
+	github.com/kr/pretty v0.3.1 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
 	github.com/matttproud/golang_protobuf_extensions v1.0.4 // indirect
 	github.com/prometheus/client_model v0.3.0 // indirect
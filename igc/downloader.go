@@ -0,0 +1,127 @@
+package igc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fahy.xyz/xcontestextractor/metrics"
+	"fahy.xyz/xcontestextractor/parser"
+	"github.com/sqooba/go-common/logging"
+)
+
+var (
+	log = logging.NewLogger()
+)
+
+var (
+	// ErrNoTrack is returned when the url of the track of a flight is unknown.
+	ErrNoTrack = errors.New("no track for the flight")
+)
+
+// Downloader retrieves the IGC tracks of the flights and stores them in a directory.
+type Downloader struct {
+	fetcher *parser.Fetcher
+	dir     string
+	// Template of the url of the tracks, `{key}` being replaced by the key of the flight.
+	urlTemplate string
+}
+
+// NewDownloader creates a new instance of the Downloader, creating the directory if needed.
+//
+// The url of a track is the link of the page of the flight if any, built from the template otherwise.
+// No url is built if the template is empty.
+func NewDownloader(fetcher *parser.Fetcher, dir string, urlTemplate string) (*Downloader, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Downloader{fetcher: fetcher, dir: dir, urlTemplate: urlTemplate}, nil
+}
+
+// FileName returns the name of the IGC file of a flight, e.g. `Nicober_7.11.2021_13-13.igc`.
+func FileName(flightUrl string) (string, error) {
+	key, err := parser.ExtractFlightKey(flightUrl)
+	if err != nil {
+		return "", err
+	}
+	name := strings.NewReplacer("detail:", "", "/", "_", ":", "-").Replace(key)
+	return name + ".igc", nil
+}
+
+// trackUrl returns the url of the track of a flight.
+func (d *Downloader) trackUrl(flight *parser.Flight) (string, error) {
+	if flight.TrackUrl != "" {
+		return flight.TrackUrl, nil
+	}
+	if d.urlTemplate == "" {
+		return "", ErrNoTrack
+	}
+	key, err := parser.ExtractFlightKey(flight.Url)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(d.urlTemplate, "{key}", key), nil
+}
+
+// Download retrieves the track of a flight, parses it and stores the raw file.
+func (d *Downloader) Download(ctx context.Context, flight *parser.Flight) (*Track, error) {
+	url, err := d.trackUrl(flight)
+	if err != nil {
+		return nil, err
+	}
+	name, err := FileName(flight.Url)
+	if err != nil {
+		return nil, err
+	}
+	response, err := d.fetcher.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	// An answer which is not a track, e.g. a login page, is not stored.
+	track, err := Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(filepath.Join(d.dir, name), content, 0o644); err != nil {
+		return nil, err
+	}
+	return track, nil
+}
+
+// Process downloads the track of a flight and adds its statistics to the flight.
+func (d *Downloader) Process(ctx context.Context, flight *parser.Flight) error {
+	track, err := d.Download(ctx, flight)
+	if err != nil {
+		return err
+	}
+	stats := track.Stats()
+	flight.MaxClimb = stats.MaxClimb
+	flight.ElevationGain = int64(stats.ElevationGain)
+	flight.MaxTakeOffDistance = stats.MaxTakeOffDistance
+	return nil
+}
+
+// ProcessOrSkip processes the track of a flight, the failures being only logged and counted.
+//
+// The flight is still inserted without statistics if the track cannot be downloaded.
+func (d *Downloader) ProcessOrSkip(ctx context.Context, flight *parser.Flight) {
+	err := d.Process(ctx, flight)
+	switch {
+	case errors.Is(err, ErrNoTrack):
+		log.Debugf("No track for flight %s", flight.Url)
+	case err != nil:
+		metrics.ErrorsTotal.Inc()
+		log.Warningf("Error processing the track of flight %s: %v", flight.Url, err)
+	default:
+		metrics.TracksTotal.Inc()
+	}
+}
@@ -0,0 +1,89 @@
+package igc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fahy.xyz/xcontestextractor/parser"
+)
+
+func TestFileName(t *testing.T) {
+	name, err := FileName("https://www.xcontest.org/world/en/flights/detail:Nicober/7.11.2021/13:13")
+	if err != nil {
+		t.Fatalf("Error computing the file name: %v", err)
+	}
+	if name != "Nicober_7.11.2021_13-13.igc" {
+		t.Errorf("File name is wrong: %s", name)
+	}
+}
+
+func TestDownloaderProcess(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer server.Close()
+
+	dir := t.TempDir()
+	downloader, err := NewDownloader(parser.NewFetcher(server.Client(), nil, nil), dir, server.URL+"/{key}.igc")
+	if err != nil {
+		t.Fatalf("Error creating the downloader: %v", err)
+	}
+	flight := &parser.Flight{
+		Url:      "https://www.xcontest.org/world/en/flights/detail:Claricegomes/5.12.2021/14:23",
+		TrackUrl: server.URL + "/flight.igc",
+	}
+	if err = downloader.Process(context.Background(), flight); err != nil {
+		t.Fatalf("Error processing the track: %v", err)
+	}
+	if flight.MaxClimb != 4.5 || flight.ElevationGain != 210 {
+		t.Errorf("Statistics of the flight are wrong: %+v", flight)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "Claricegomes_5.12.2021_14-23.igc")); err != nil {
+		t.Errorf("Track not stored: %v", err)
+	}
+
+	// Without link on the page, the url is built from the template.
+	flight.TrackUrl = ""
+	if _, err = downloader.Download(context.Background(), flight); err == nil {
+		t.Errorf("Track downloaded from a missing url")
+	}
+	var statusError *parser.StatusError
+	if !errors.As(err, &statusError) || statusError.StatusCode != 404 {
+		t.Errorf("Expected a not found error, got: %v", err)
+	}
+}
+
+func TestDownloaderNoTrack(t *testing.T) {
+	downloader, err := NewDownloader(parser.NewFetcher(nil, nil, nil), t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("Error creating the downloader: %v", err)
+	}
+	flight := &parser.Flight{Url: "https://www.xcontest.org/world/en/flights/detail:Claricegomes/5.12.2021/14:23"}
+	if _, err = downloader.Download(context.Background(), flight); !errors.Is(err, ErrNoTrack) {
+		t.Errorf("Expected ErrNoTrack, got: %v", err)
+	}
+}
+
+func TestDownloaderInvalidTrack(t *testing.T) {
+	// A login page answered instead of the track.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><body><form id=\"login\"></form></body></html>"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	downloader, err := NewDownloader(parser.NewFetcher(server.Client(), nil, nil), dir, server.URL+"/{key}.igc")
+	if err != nil {
+		t.Fatalf("Error creating the downloader: %v", err)
+	}
+	flight := &parser.Flight{Url: "https://www.xcontest.org/world/en/flights/detail:Claricegomes/5.12.2021/14:23"}
+	if _, err = downloader.Download(context.Background(), flight); err == nil {
+		t.Fatal("Expected an error for a page which is not a track")
+	}
+	if _, err = os.Stat(filepath.Join(dir, "Claricegomes_5.12.2021_14-23.igc")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Invalid track stored: %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package igc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Length of a B-record without extensions.
+	bRecordLength = 35
+	// Minimal duration over which the climb rate is computed, to smooth the noise of the fixes.
+	climbWindow = 20 * time.Second
+	// Mean radius of the earth in km.
+	earthRadius = 6371.0
+)
+
+// Fix is a position of a track, recorded in a B-record.
+type Fix struct {
+	Time      time.Time
+	Latitude  float64
+	Longitude float64
+	// Valid is false if the fix has no 3D position.
+	Valid            bool
+	PressureAltitude int
+	GPSAltitude      int
+}
+
+// Altitude returns the GPS altitude of the fix, or the pressure altitude if the GPS one is not recorded.
+func (f Fix) Altitude() int {
+	if f.GPSAltitude != 0 {
+		return f.GPSAltitude
+	}
+	return f.PressureAltitude
+}
+
+// hasPosition returns true if the fix has a valid position, the recorders writing 0/0 before acquiring one.
+func (f Fix) hasPosition() bool {
+	return f.Valid && (f.Latitude != 0 || f.Longitude != 0)
+}
+
+// Track is a flight recorded in an IGC file.
+type Track struct {
+	Date   time.Time
+	Pilot  string
+	Glider string
+	Fixes  []Fix
+}
+
+// Stats are the statistics derived from the fixes of a track.
+type Stats struct {
+	// MaxClimb is the best climb rate in m/s.
+	MaxClimb float64
+	// ElevationGain is the sum of all the altitude gains in m.
+	ElevationGain int
+	// MaxTakeOffDistance is the maximal distance from the first valid fix in km.
+	MaxTakeOffDistance float64
+	Duration           time.Duration
+}
+
+// parseDate parses the date of a HFDTE record, either `HFDTE071121` or `HFDTEDATE:071121,01`.
+func parseDate(line string) (time.Time, error) {
+	value := strings.TrimPrefix(line[5:], "DATE:")
+	if len(value) < 6 {
+		return time.Time{}, fmt.Errorf("invalid date record: %s", line)
+	}
+	return time.Parse("020106", value[:6])
+}
+
+// parseHeaderValue returns the value of a header record, e.g. `HFPLTPILOTINCHARGE:John Doe`.
+func parseHeaderValue(line string) string {
+	if _, value, found := strings.Cut(line, ":"); found {
+		return strings.TrimSpace(value)
+	}
+	return ""
+}
+
+// parseCoordinate converts degrees and minutes with thousandths, e.g. `4612345N` for 46°12.345'.
+func parseCoordinate(value string, degreeDigits int) (float64, error) {
+	degrees, err := strconv.Atoi(value[:degreeDigits])
+	if err != nil {
+		return 0, err
+	}
+	thousandths, err := strconv.Atoi(value[degreeDigits : len(value)-1])
+	if err != nil {
+		return 0, err
+	}
+	coordinate := float64(degrees) + float64(thousandths)/60000
+	switch value[len(value)-1] {
+	case 'S', 'W':
+		coordinate = -coordinate
+	case 'N', 'E':
+	default:
+		return 0, fmt.Errorf("invalid hemisphere in %s", value)
+	}
+	return coordinate, nil
+}
+
+// parseFix parses a B-record, e.g. `B1101355206343N00006198WA0058700558`.
+func parseFix(line string, date time.Time) (Fix, error) {
+	if len(line) < bRecordLength {
+		return Fix{}, fmt.Errorf("B-record too short: %s", line)
+	}
+	var fix Fix
+	clock, err := time.Parse("150405", line[1:7])
+	if err != nil {
+		return fix, err
+	}
+	fix.Time = date.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute + time.Duration(clock.Second())*time.Second)
+	if fix.Latitude, err = parseCoordinate(line[7:15], 2); err != nil {
+		return fix, err
+	}
+	if fix.Longitude, err = parseCoordinate(line[15:24], 3); err != nil {
+		return fix, err
+	}
+	fix.Valid = line[24] == 'A'
+	if fix.PressureAltitude, err = strconv.Atoi(line[25:30]); err != nil {
+		return fix, err
+	}
+	if fix.GPSAltitude, err = strconv.Atoi(line[30:35]); err != nil {
+		return fix, err
+	}
+	return fix, nil
+}
+
+// Parse reads the header and the fixes of an IGC file.
+//
+// The other records are ignored.
+func Parse(r io.Reader) (*Track, error) {
+	track := &Track{}
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "HFDTE"):
+			date, err := parseDate(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			track.Date = date
+		case strings.HasPrefix(line, "HFPLT"):
+			track.Pilot = parseHeaderValue(line)
+		case strings.HasPrefix(line, "HFGTY"):
+			track.Glider = parseHeaderValue(line)
+		case strings.HasPrefix(line, "B"):
+			fix, err := parseFix(line, track.Date)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			// Flights going over midnight UTC.
+			if n := len(track.Fixes); n > 0 && fix.Time.Before(track.Fixes[n-1].Time) {
+				fix.Time = fix.Time.Add(24 * time.Hour)
+			}
+			track.Fixes = append(track.Fixes, fix)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(track.Fixes) == 0 {
+		return nil, fmt.Errorf("no fix in the track")
+	}
+	return track, nil
+}
+
+// Distance returns the great-circle distance between two fixes in km.
+func Distance(a Fix, b Fix) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	deltaLat := lat2 - lat1
+	deltaLon := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// Stats computes the statistics of the track.
+func (t *Track) Stats() Stats {
+	var stats Stats
+	if len(t.Fixes) == 0 {
+		return stats
+	}
+	stats.Duration = t.Fixes[len(t.Fixes)-1].Time.Sub(t.Fixes[0].Time)
+	// The take-off is the first fix with a position, the fixes without one being ignored for the distance.
+	var takeOff *Fix
+	start := 0
+	for i, fix := range t.Fixes {
+		if fix.hasPosition() {
+			if takeOff == nil {
+				takeOff = &t.Fixes[i]
+			}
+			if distance := Distance(*takeOff, fix); distance > stats.MaxTakeOffDistance {
+				stats.MaxTakeOffDistance = distance
+			}
+		}
+		if i == 0 {
+			continue
+		}
+		if gain := fix.Altitude() - t.Fixes[i-1].Altitude(); gain > 0 {
+			stats.ElevationGain += gain
+		}
+		// Shortest window ending at this fix lasting at least climbWindow.
+		for start+1 < i && fix.Time.Sub(t.Fixes[start+1].Time) >= climbWindow {
+			start++
+		}
+		window := fix.Time.Sub(t.Fixes[start].Time)
+		if window < climbWindow {
+			continue
+		}
+		climb := float64(fix.Altitude()-t.Fixes[start].Altitude()) / window.Seconds()
+		if climb > stats.MaxClimb {
+			stats.MaxClimb = climb
+		}
+	}
+	return stats
+}
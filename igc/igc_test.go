@@ -0,0 +1,104 @@
+package igc
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "flight.igc"))
+	if err != nil {
+		t.Fatalf("Error reading file: %v", err)
+	}
+	defer file.Close()
+
+	track, err := Parse(file)
+	if err != nil {
+		t.Fatalf("Error parsing the track: %v", err)
+	}
+	if track.Pilot != "Clarice Mendes Gomes" || track.Glider != "OZONE Swift 5" {
+		t.Errorf("Retrieved header is wrong: %s, %s", track.Pilot, track.Glider)
+	}
+	if len(track.Fixes) != 10 {
+		t.Fatalf("Expected 10 fixes, got %d", len(track.Fixes))
+	}
+	first := track.Fixes[0]
+	if !first.Time.Equal(time.Date(2021, 12, 5, 17, 23, 0, 0, time.UTC)) {
+		t.Errorf("Retrieved time is wrong: %s", first.Time)
+	}
+	if math.Abs(first.Latitude+23.1452) > 1e-6 || math.Abs(first.Longitude+52.323400) > 1e-6 {
+		t.Errorf("Retrieved coordinates are wrong: %f, %f", first.Latitude, first.Longitude)
+	}
+	if !first.Valid || first.PressureAltitude != 480 || first.GPSAltitude != 500 {
+		t.Errorf("Retrieved fix is wrong: %+v", first)
+	}
+}
+
+func TestParseMidnight(t *testing.T) {
+	content := "HFDTE311221\nB2359504612345N00806789EA0100001000\nB0000104612345N00806789EA0100001000\n"
+	track, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Error parsing the track: %v", err)
+	}
+	if duration := track.Fixes[1].Time.Sub(track.Fixes[0].Time); duration != 20*time.Second {
+		t.Errorf("Duration over midnight is wrong: %s", duration)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := Parse(strings.NewReader("HFDTE311221\nB2359504612345X00806789EA0100001000\n")); err == nil {
+		t.Errorf("Invalid fix parsed")
+	}
+	if _, err := Parse(strings.NewReader("HFDTE311221\n")); err == nil {
+		t.Errorf("Track without fix parsed")
+	}
+}
+
+func TestStats(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "flight.igc"))
+	if err != nil {
+		t.Fatalf("Error reading file: %v", err)
+	}
+	defer file.Close()
+
+	track, err := Parse(file)
+	if err != nil {
+		t.Fatalf("Error parsing the track: %v", err)
+	}
+	stats := track.Stats()
+	if stats.MaxClimb != 4.5 {
+		t.Errorf("Max climb is wrong: %f", stats.MaxClimb)
+	}
+	if stats.ElevationGain != 210 {
+		t.Errorf("Elevation gain is wrong: %d", stats.ElevationGain)
+	}
+	if math.Abs(stats.MaxTakeOffDistance-7.4131) > 1e-3 {
+		t.Errorf("Max distance from take-off is wrong: %f", stats.MaxTakeOffDistance)
+	}
+	if stats.Duration != 90*time.Second {
+		t.Errorf("Duration is wrong: %s", stats.Duration)
+	}
+}
+
+func TestStatsInvalidFixes(t *testing.T) {
+	date := time.Date(2021, 12, 5, 17, 23, 0, 0, time.UTC)
+	track := &Track{Fixes: []Fix{
+		{Time: date, Valid: false, Latitude: -23.0452, Longitude: -52.3234},
+		{Time: date.Add(10 * time.Second), Valid: true},
+		{Time: date.Add(20 * time.Second), Valid: true, Latitude: -23.1452, Longitude: -52.3234},
+		{Time: date.Add(30 * time.Second), Valid: false},
+		{Time: date.Add(40 * time.Second), Valid: true, Latitude: -23.1452, Longitude: -52.3234},
+	}}
+	// Neither the fix without 3D position nor the ones at 0/0 are the take-off.
+	stats := track.Stats()
+	if stats.MaxTakeOffDistance != 0 {
+		t.Errorf("Max distance from take-off is wrong: %f", stats.MaxTakeOffDistance)
+	}
+	if stats.Duration != 40*time.Second {
+		t.Errorf("Duration is wrong: %s", stats.Duration)
+	}
+}
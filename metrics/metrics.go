@@ -8,12 +8,23 @@ import (
 )
 
 var (
+	BrowserPagesTotal          prometheus.Counter
+	BrowserRestartsTotal       *prometheus.CounterVec
+	BrowserRunning             prometheus.Gauge
+	BrowserStartsTotal         prometheus.Counter
+	BulkFailedItemsTotal       prometheus.Counter
+	CrawlBackoffSeconds        *prometheus.GaugeVec
+	CrawlThrottledTotal        *prometheus.CounterVec
+	CrawlTokens                *prometheus.GaugeVec
+	DeadLettersTotal           prometheus.Counter
 	DocumentsTotal             prometheus.Counter
 	DuplicatesTotal            prometheus.Counter
 	ErrorsTotal                prometheus.Counter
+	FailedFlights              prometheus.Gauge
 	HttpRequestDurationSeconds prometheus.Summary
 	HttpRequestsTotal          prometheus.Counter
 	RunsTotal                  prometheus.Counter
+	TracksTotal                prometheus.Counter
 )
 
 type Config struct {
@@ -23,6 +34,78 @@ type Config struct {
 }
 
 func InitPrometheus(config Config, mux *http.ServeMux) {
+	BrowserPagesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:      "browser_pages_total",
+		Help:      "Number of pages rendered by the headless browser.",
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+	})
+	prometheus.MustRegister(BrowserPagesTotal)
+
+	BrowserRestartsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "browser_restarts_total",
+		Help:      "Number of restarts of the headless browser.",
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+	}, []string{"reason"})
+	prometheus.MustRegister(BrowserRestartsTotal)
+
+	BrowserRunning = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:      "browser_running",
+		Help:      "Whether the headless browser is running.",
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+	})
+	prometheus.MustRegister(BrowserRunning)
+
+	BrowserStartsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:      "browser_starts_total",
+		Help:      "Number of starts of the headless browser.",
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+	})
+	prometheus.MustRegister(BrowserStartsTotal)
+
+	BulkFailedItemsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:      "bulk_failed_items_total",
+		Help:      "Number of documents rejected in bulk requests.",
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+	})
+	prometheus.MustRegister(BulkFailedItemsTotal)
+
+	CrawlBackoffSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "crawl_backoff_seconds",
+		Help:      "Remaining backoff before the next request to a host.",
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+	}, []string{"host"})
+	prometheus.MustRegister(CrawlBackoffSeconds)
+
+	CrawlThrottledTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "crawl_throttled_total",
+		Help:      "Number of requests throttled by a host.",
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+	}, []string{"host"})
+	prometheus.MustRegister(CrawlThrottledTotal)
+
+	CrawlTokens = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "crawl_tokens",
+		Help:      "Number of requests available in the token bucket of a host.",
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+	}, []string{"host"})
+	prometheus.MustRegister(CrawlTokens)
+
+	DeadLettersTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:      "dead_letters_total",
+		Help:      "Number of flights saved as dead letters.",
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+	})
+	prometheus.MustRegister(DeadLettersTotal)
+
 	DocumentsTotal = prometheus.NewCounter(prometheus.CounterOpts{
 		Name:      "documents_total",
 		Help:      "Number of documents inserted.",
@@ -47,6 +130,14 @@ func InitPrometheus(config Config, mux *http.ServeMux) {
 	})
 	prometheus.MustRegister(ErrorsTotal)
 
+	FailedFlights = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:      "failed_flights",
+		Help:      "Number of failed flights waiting to be retried.",
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+	})
+	prometheus.MustRegister(FailedFlights)
+
 	HttpRequestDurationSeconds = prometheus.NewSummary(prometheus.SummaryOpts{
 		Name:      "http_request_duration_seconds",
 		Help:      "Duration of http requests",
@@ -71,5 +162,13 @@ func InitPrometheus(config Config, mux *http.ServeMux) {
 	})
 	prometheus.MustRegister(RunsTotal)
 
+	TracksTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:      "tracks_total",
+		Help:      "Number of IGC tracks downloaded.",
+		Namespace: config.Namespace,
+		Subsystem: config.Subsystem,
+	})
+	prometheus.MustRegister(TracksTotal)
+
 	mux.Handle(config.Path, promhttp.Handler())
 }
@@ -0,0 +1,198 @@
+package pagesource
+
+import (
+	"context"
+	"sync"
+
+	"fahy.xyz/xcontestextractor/crawl"
+	"fahy.xyz/xcontestextractor/metrics"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// Default elements waited for before reading a page of the archive and of a flight.
+	defaultWaitSelector       = "table.XClist"
+	defaultDetailWaitSelector = "table.XCinfo"
+	// Reasons of the restarts of the browser.
+	restartMaxPages = "max_pages"
+	restartError    = "error"
+)
+
+// BrowserSource downloads the pages of the archive with a headless browser, which renders the javascript.
+// It also renders the pages of the flights, as a parser.Renderer.
+//
+// The browser is started on the first page and kept running between the pages, each page being rendered in a new tab.
+// It is restarted after MaxBrowserPages pages or after a failure. The pages are rendered one at a time.
+type BrowserSource struct {
+	config Config
+	policy *crawl.Policy
+	mutex  sync.Mutex
+	// Context of the running browser, nil if not started.
+	browserCtx context.Context
+	cancel     context.CancelFunc
+	// Number of pages rendered by the running browser.
+	pages int
+}
+
+// NewBrowserSource creates a new instance of the BrowserSource.
+func NewBrowserSource(config Config, policy *crawl.Policy) *BrowserSource {
+	if config.WaitSelector == "" {
+		config.WaitSelector = defaultWaitSelector
+	}
+	if config.DetailWaitSelector == "" {
+		config.DetailWaitSelector = defaultDetailWaitSelector
+	}
+	return &BrowserSource{config: config, policy: policy}
+}
+
+// start launches the browser.
+func (s *BrowserSource) start() error {
+	opts := []chromedp.ExecAllocatorOption{
+		chromedp.NoFirstRun,
+		chromedp.NoDefaultBrowserCheck,
+		chromedp.Headless,
+		chromedp.UserAgent(s.config.UserAgent),
+		chromedp.DisableGPU,
+	}
+	if s.config.BrowserPath != "" {
+		opts = append(opts, chromedp.ExecPath(s.config.BrowserPath))
+	}
+
+	// The browser outlives the requests, it is only stopped by Close or a restart.
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	browserCtx, browserCancel := chromedp.NewContext(
+		allocCtx,
+		chromedp.WithLogf(log.Printf),
+	)
+	// Running an empty action starts the browser.
+	if err := chromedp.Run(browserCtx); err != nil {
+		browserCancel()
+		allocCancel()
+		return err
+	}
+	log.Info("Browser started")
+	s.browserCtx = browserCtx
+	s.cancel = func() {
+		browserCancel()
+		allocCancel()
+	}
+	s.pages = 0
+	if metrics.BrowserStartsTotal != nil {
+		metrics.BrowserStartsTotal.Inc()
+		metrics.BrowserRunning.Set(1)
+	}
+	return nil
+}
+
+// stop closes the browser if it is running.
+func (s *BrowserSource) stop() {
+	if s.browserCtx == nil {
+		return
+	}
+	s.cancel()
+	s.browserCtx = nil
+	s.cancel = nil
+	log.Info("Browser stopped")
+	if metrics.BrowserRunning != nil {
+		metrics.BrowserRunning.Set(0)
+	}
+}
+
+// restart stops the browser so that it is started again on the next page.
+func (s *BrowserSource) restart(reason string) {
+	log.Infof("Restarting the browser (%s)", reason)
+	s.stop()
+	if metrics.BrowserRestartsTotal != nil {
+		metrics.BrowserRestartsTotal.WithLabelValues(reason).Inc()
+	}
+}
+
+// GetPage renders the page in a new tab and extracts the body of its flights table.
+func (s *BrowserSource) GetPage(ctx context.Context, url string) (string, error) {
+	const sel = "html body div#page.sect-cpp div#page-inner div#main-box div.in1 div#content-and-context div#content div.under-bar div#flights.XContest table.XClist tbody"
+
+	var res string
+	err := s.run(ctx, url,
+		chromedp.WaitVisible(s.config.WaitSelector),
+		chromedp.OuterHTML(sel, &res, chromedp.BySearch),
+	)
+	return res, err
+}
+
+// Render renders the page of a flight in a new tab and returns its whole document.
+func (s *BrowserSource) Render(ctx context.Context, url string) (string, error) {
+	var res string
+	err := s.run(ctx, url,
+		chromedp.WaitVisible(s.config.DetailWaitSelector),
+		chromedp.OuterHTML("html", &res, chromedp.ByQuery),
+	)
+	return res, err
+}
+
+// run navigates to the url in a new tab and runs the actions reading the page.
+func (s *BrowserSource) run(ctx context.Context, url string, actions ...chromedp.Action) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	host := crawl.Host(url)
+	if err := s.policy.Wait(ctx, host); err != nil {
+		return err
+	}
+
+	if s.config.MaxBrowserPages > 0 && s.pages >= s.config.MaxBrowserPages {
+		s.restart(restartMaxPages)
+	}
+	// The browser is also restarted when it exited on its own.
+	if s.browserCtx != nil && s.browserCtx.Err() != nil {
+		s.restart(restartError)
+	}
+	if s.browserCtx == nil {
+		if err := s.start(); err != nil {
+			log.Errorf("Error starting the browser: %v", err)
+			s.policy.Backoff(host, 0)
+			return err
+		}
+	}
+
+	tabCtx, tabCancel := chromedp.NewContext(s.browserCtx)
+	defer tabCancel()
+	// Close the tab when the request is cancelled.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			tabCancel()
+		case <-done:
+		}
+	}()
+
+	timeoutCtx, timeoutCancel := context.WithTimeout(tabCtx, s.config.Timeout)
+	defer timeoutCancel()
+
+	s.pages++
+	if metrics.BrowserPagesTotal != nil {
+		metrics.BrowserPagesTotal.Inc()
+	}
+	err := chromedp.Run(timeoutCtx, append([]chromedp.Action{chromedp.Navigate(url)}, actions...)...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Errorf("Error navigating the page: %v", err)
+		// The browser may be stuck, a fresh one is used for the next page.
+		s.restart(restartError)
+		s.policy.Backoff(host, 0)
+		return err
+	}
+	s.policy.Success(host)
+	return nil
+}
+
+// Close stops the browser.
+func (s *BrowserSource) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stop()
+	return nil
+}
@@ -0,0 +1,112 @@
+package pagesource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+
+	"fahy.xyz/xcontestextractor/crawl"
+	"fahy.xyz/xcontestextractor/parser"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// HTTPSource downloads the pages of the archive with plain HTTP requests, without any browser.
+//
+// The cookies are kept between the requests, and a session is opened on the home page of
+// each host before requesting its first page.
+type HTTPSource struct {
+	fetcher  *parser.Fetcher
+	mutex    sync.Mutex
+	sessions map[string]bool
+}
+
+// NewHTTPSource creates a new instance of the HTTPSource.
+//
+// A cookie jar is added to the client if it does not have any.
+func NewHTTPSource(client *http.Client, header http.Header, policy *crawl.Policy) (*HTTPSource, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
+	if client.Jar == nil {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, err
+		}
+		withJar := *client
+		withJar.Jar = jar
+		client = &withJar
+	}
+	return &HTTPSource{
+		fetcher:  parser.NewFetcher(client, header, policy),
+		sessions: make(map[string]bool),
+	}, nil
+}
+
+// ListUrl converts the url of a page of the archive rendered by javascript, e.g. `.../flights/#flights[start]=100`,
+// into the url of the same list rendered by the server, e.g. `.../flights/?list[start]=100`.
+func ListUrl(pageUrl string) string {
+	base, fragment, found := strings.Cut(pageUrl, "#")
+	if !found {
+		return pageUrl
+	}
+	query := strings.ReplaceAll(fragment, "flights[", "list[")
+	if strings.Contains(base, "?") {
+		return base + "&" + query
+	}
+	return base + "?" + query
+}
+
+// startSession requests the home page of the host of the url once to get the session cookies.
+func (s *HTTPSource) startSession(ctx context.Context, pageUrl string) error {
+	parsed, err := url.Parse(pageUrl)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.sessions[parsed.Host] {
+		return nil
+	}
+	home := parsed.Scheme + "://" + parsed.Host + "/"
+	log.Debugf("Starting session on %s", home)
+	response, err := s.fetcher.Get(ctx, home)
+	if err != nil {
+		return fmt.Errorf("error starting session on %s: %w", home, err)
+	}
+	response.Body.Close()
+	s.sessions[parsed.Host] = true
+	return nil
+}
+
+// GetPage downloads the page and extracts the body of its flights table.
+func (s *HTTPSource) GetPage(ctx context.Context, pageUrl string) (string, error) {
+	if err := s.startSession(ctx, pageUrl); err != nil {
+		return "", err
+	}
+	response, err := s.fetcher.Get(ctx, ListUrl(pageUrl))
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(response.Body)
+	if err != nil {
+		return "", err
+	}
+	table := doc.Find("table.XClist tbody")
+	if table.Length() == 0 {
+		log.Debugf("No flights table on page %s", pageUrl)
+		return "", nil
+	}
+	return goquery.OuterHtml(table.First())
+}
+
+// Close releases the resources of the source.
+func (s *HTTPSource) Close() error {
+	s.fetcher.Client.CloseIdleConnections()
+	return nil
+}
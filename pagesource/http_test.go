@@ -0,0 +1,89 @@
+package pagesource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"fahy.xyz/xcontestextractor/parser"
+)
+
+func TestListUrl(t *testing.T) {
+	tests := map[string]string{
+		"https://www.xcontest.org/2021/world/en/flights/#flights[start]=100":                  "https://www.xcontest.org/2021/world/en/flights/?list[start]=100",
+		"https://www.xcontest.org/2021/world/en/flights/?filter[country]=CH#flights[start]=0": "https://www.xcontest.org/2021/world/en/flights/?filter[country]=CH&list[start]=0",
+		"https://www.xcontest.org/2021/world/en/flights/?list[start]=200":                     "https://www.xcontest.org/2021/world/en/flights/?list[start]=200",
+	}
+	for input, expected := range tests {
+		if result := ListUrl(input); result != expected {
+			t.Errorf("ListUrl(%s) = %s, expected %s", input, result, expected)
+		}
+	}
+}
+
+// newArchiveServer serves the flights table on the first page only, to clients having a session cookie.
+func newArchiveServer(t *testing.T) *httptest.Server {
+	table, err := os.ReadFile("../parser/testdata/flights_2021_table.html")
+	if err != nil {
+		t.Fatalf("Error reading the test file: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "PHPSESSID", Value: "session"})
+	})
+	mux.HandleFunc("/2021/world/en/flights/", func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("PHPSESSID"); err != nil || cookie.Value != "session" {
+			http.Error(w, "no session", http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `<html><body><div id="flights" class="XContest"><table class="XClist">`)
+		if r.URL.Query().Get("list[start]") == "0" {
+			w.Write(table)
+		}
+		fmt.Fprint(w, `</table></div></body></html>`)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestHTTPSourceGetPage(t *testing.T) {
+	server := newArchiveServer(t)
+	defer server.Close()
+
+	source, err := NewHTTPSource(server.Client(), nil, nil)
+	if err != nil {
+		t.Fatalf("Error creating the source: %v", err)
+	}
+	defer source.Close()
+
+	page, err := source.GetPage(context.Background(), server.URL+"/2021/world/en/flights/#flights[start]=0")
+	if err != nil {
+		t.Fatalf("Error getting the page: %v", err)
+	}
+	entries, _ := parser.ParseFlightsTable(strings.NewReader(page))
+	if len(entries) != 4 {
+		t.Errorf("Expected 4 entries, got %d", len(entries))
+	}
+}
+
+func TestHTTPSourceEmptyPage(t *testing.T) {
+	server := newArchiveServer(t)
+	defer server.Close()
+
+	source, err := NewHTTPSource(server.Client(), nil, nil)
+	if err != nil {
+		t.Fatalf("Error creating the source: %v", err)
+	}
+	defer source.Close()
+
+	page, err := source.GetPage(context.Background(), server.URL+"/2021/world/en/flights/#flights[start]=100")
+	if err != nil {
+		t.Fatalf("Error getting the page: %v", err)
+	}
+	if strings.TrimSpace(page) != "" {
+		t.Errorf("Expected an empty page, got %q", page)
+	}
+}
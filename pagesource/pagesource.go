@@ -0,0 +1,59 @@
+package pagesource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"fahy.xyz/xcontestextractor/crawl"
+	"github.com/sqooba/go-common/logging"
+)
+
+const (
+	ModeHTTP    = "http"
+	ModeBrowser = "browser"
+)
+
+var (
+	log = logging.NewLogger()
+)
+
+// Source downloads the flights table of the pages of the archive.
+type Source interface {
+	// GetPage returns the HTML of the body of the flights table, empty if the page has no flight.
+	GetPage(ctx context.Context, url string) (string, error)
+	// Close releases the resources of the source.
+	Close() error
+}
+
+// Config contains the settings of the page sources.
+type Config struct {
+	// Mode is the kind of source, either ModeHTTP or ModeBrowser.
+	Mode string
+	// Timeout of the download of a page.
+	Timeout time.Duration
+	// UserAgent sent with the requests.
+	UserAgent string
+	// BrowserPath is the path of the headless browser, looked up in the PATH if empty.
+	BrowserPath string
+	// WaitSelector is the element waited for before reading a page rendered by the browser.
+	WaitSelector string
+	// DetailWaitSelector is the element waited for before reading a page of a flight rendered by the browser.
+	DetailWaitSelector string
+	// MaxBrowserPages is the number of pages rendered before restarting the browser, never restarted if zero.
+	MaxBrowserPages int
+}
+
+// New creates the page source selected in the configuration.
+func New(config Config, policy *crawl.Policy) (Source, error) {
+	switch config.Mode {
+	case ModeHTTP:
+		client := &http.Client{Timeout: config.Timeout}
+		header := http.Header{"User-Agent": []string{config.UserAgent}}
+		return NewHTTPSource(client, header, policy)
+	case ModeBrowser:
+		return NewBrowserSource(config, policy), nil
+	}
+	return nil, fmt.Errorf("unknown page source: %s", config.Mode)
+}
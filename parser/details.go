@@ -0,0 +1,162 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	regexNumber     = regexp.MustCompile(`[0-9]+(\.[0-9]+)?`)
+	regexTime       = regexp.MustCompile(`[0-9]{1,2}:[0-9]{2}(:[0-9]{2})?`)
+	regexCoordinate = regexp.MustCompile(`([NSEW]) ?([0-9]+)°([0-9.]+)'`)
+	regexPoint      = regexp.MustCompile(`filter\[point\]=(-?[0-9.]+)(?: |\+|%20)(-?[0-9.]+)`)
+)
+
+// Turnpoint represents a point of the route of a flight.
+type Turnpoint struct {
+	Name      string  `json:"name"`
+	Time      string  `json:"time,omitempty"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// Distance from the previous point in km.
+	Distance float64 `json:"distance,omitempty"`
+}
+
+// parseNumber converts the first number of the text, e.g. `103.58 p.`.
+func parseNumber(text string) (float64, error) {
+	match := regexNumber.FindString(text)
+	return strconv.ParseFloat(match, 64)
+}
+
+// parseCoordinate converts a coordinate in degrees and decimal minutes, e.g. `S 23°08.712'`.
+func parseCoordinate(text string) (float64, error) {
+	match := regexCoordinate.FindStringSubmatch(text)
+	if len(match) == 0 {
+		return 0, &strconv.NumError{Func: "parseCoordinate", Num: text, Err: strconv.ErrSyntax}
+	}
+	degrees, err := strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := strconv.ParseFloat(match[3], 64)
+	if err != nil {
+		return 0, err
+	}
+	coordinate := degrees + minutes/60
+	if match[1] == "S" || match[1] == "W" {
+		coordinate = -coordinate
+	}
+	return coordinate, nil
+}
+
+// infoRows returns the value cells of the information tables of a flight, by label.
+//
+// The labels are lower case and without the trailing colon, e.g. `glider`.
+func infoRows(doc *goquery.Document) map[string]*goquery.Selection {
+	rows := make(map[string]*goquery.Selection)
+	doc.Find("table.XCinfo tr").Each(func(_ int, row *goquery.Selection) {
+		label := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(row.Find("th").First().Text()), ":"))
+		if label != "" {
+			rows[label] = row.Find("td").First()
+		}
+	})
+	return rows
+}
+
+// parseDetails extracts the information only available on the page of a flight rendered by a browser.
+//
+// The details are optional: the missing or invalid ones are left empty, e.g. on the pages downloaded
+// with plain HTTP requests, where the tables are rendered by javascript.
+func parseDetails(doc *goquery.Document, flight *Flight) {
+	var err error
+	rows := infoRows(doc)
+	if club, ok := rows["club"]; ok {
+		flight.Club = strings.TrimSpace(club.Text())
+	}
+	if glider, ok := rows["glider"]; ok {
+		category := glider.Find("span").First()
+		flight.GliderClass = strings.TrimSpace(category.AttrOr("title", category.Text()))
+		category.Remove()
+		flight.Glider = strings.TrimSpace(glider.Text())
+	}
+	if points, ok := rows["points"]; ok {
+		if flight.Points, err = parseNumber(points.Text()); err != nil {
+			log.Warningf("Error converting points: %v", err)
+		}
+	}
+	if multiplier, ok := rows["multiplier"]; ok {
+		if flight.Multiplier, err = parseNumber(multiplier.Text()); err != nil {
+			log.Warningf("Error converting multiplier: %v", err)
+		}
+	}
+	if launch, ok := rows["launch"]; ok {
+		flight.LaunchTime = regexTime.FindString(launch.Text())
+	}
+	if landing, ok := rows["landing"]; ok {
+		flight.LandingTime = regexTime.FindString(landing.Text())
+	}
+	flight.Turnpoints = parseTurnpoints(doc)
+	flight.TakeOffLocation = parseTakeOffLocation(doc)
+	flight.TrackUrl, _ = doc.Find("a[href*='.igc']").First().Attr("href")
+}
+
+// parseTakeOffLocation extracts the location of the take-off from the link to the flights of the same take-off.
+//
+// The link contains the longitude and the latitude, e.g. `flights-search/?filter[point]=-52.3234 -23.1452`.
+func parseTakeOffLocation(doc *goquery.Document) *GeoPoint {
+	href, ok := doc.Find("a.lau").First().Attr("href")
+	if !ok {
+		return nil
+	}
+	match := regexPoint.FindStringSubmatch(href)
+	if len(match) == 0 {
+		return nil
+	}
+	lon, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return nil
+	}
+	lat, err := strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return nil
+	}
+	// Take-offs without location are at the origin.
+	if lat == 0 && lon == 0 {
+		return nil
+	}
+	return &GeoPoint{Lat: lat, Lon: lon}
+}
+
+// parseTurnpoints extracts the points of the route of a flight.
+func parseTurnpoints(doc *goquery.Document) []Turnpoint {
+	var turnpoints []Turnpoint
+	doc.Find("table.XCroute tbody tr").Each(func(_ int, row *goquery.Selection) {
+		cells := row.Find("td")
+		if cells.Length() < 3 {
+			return
+		}
+		turnpoint := Turnpoint{
+			Name: strings.TrimSpace(row.Find("th").First().Text()),
+			Time: regexTime.FindString(cells.Eq(0).Text()),
+		}
+		var err error
+		if turnpoint.Latitude, err = parseCoordinate(cells.Eq(1).Text()); err != nil {
+			log.Warningf("Error converting latitude of turnpoint %s: %v", turnpoint.Name, err)
+			return
+		}
+		if turnpoint.Longitude, err = parseCoordinate(cells.Eq(2).Text()); err != nil {
+			log.Warningf("Error converting longitude of turnpoint %s: %v", turnpoint.Name, err)
+			return
+		}
+		if cells.Length() > 3 && strings.TrimSpace(cells.Eq(3).Text()) != "" {
+			if turnpoint.Distance, err = parseNumber(cells.Eq(3).Text()); err != nil {
+				log.Warningf("Error converting distance of turnpoint %s: %v", turnpoint.Name, err)
+			}
+		}
+		turnpoints = append(turnpoints, turnpoint)
+	})
+	return turnpoints
+}
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseFlightPageDetails(t *testing.T) {
+	url := "https://www.xcontest.org/world/en/flights/detail:Claricegomes/5.12.2021/14:23"
+	file, err := os.Open(filepath.Join("testdata", "flight_detail_rendered.html"))
+	if err != nil {
+		t.Fatalf("Error reading file: %v", err)
+	}
+	defer file.Close()
+
+	flight, err := ParseFlightPage(file, url, "test")
+	if err != nil {
+		t.Fatalf("Error parsing the flight page: %v", err)
+	}
+	if flight.CountryCode != "BR" {
+		t.Errorf("Retrieved country code is wrong: %s", flight.CountryCode)
+	}
+	expected := Flight{
+		Glider:      "OZONE Swift 5",
+		GliderClass: "EN/LTF C",
+		Club:        "Clube de Voo Livre Terra Rica",
+		Points:      103.58,
+		Multiplier:  1,
+		LaunchTime:  "14:23:08",
+		LandingTime: "19:14:06",
+	}
+	details := Flight{
+		Glider:      flight.Glider,
+		GliderClass: flight.GliderClass,
+		Club:        flight.Club,
+		Points:      flight.Points,
+		Multiplier:  flight.Multiplier,
+		LaunchTime:  flight.LaunchTime,
+		LandingTime: flight.LandingTime,
+	}
+	if !reflect.DeepEqual(details, expected) {
+		t.Errorf("Retrieved details are wrong: %+v", details)
+	}
+
+	if flight.TakeOffLocation == nil || *flight.TakeOffLocation != (GeoPoint{Lat: -23.1452, Lon: -52.3234}) {
+		t.Errorf("Retrieved take-off location is wrong: %+v", flight.TakeOffLocation)
+	}
+
+	if flight.TrackUrl != "https://www.xcontest.org/track.php?t=1638725000.6221_61ad2a2f15d8c.igc" {
+		t.Errorf("Retrieved track url is wrong: %s", flight.TrackUrl)
+	}
+
+	if flight.LaunchLocalTime != "2021-12-05T14:23:00" || flight.UtcOffset != "-03:00" {
+		t.Errorf("Retrieved local launch time is wrong: %s (%s)", flight.LaunchLocalTime, flight.UtcOffset)
+	}
+	if flight.LaunchTimestamp != time.Date(2021, 12, 5, 17, 23, 0, 0, time.UTC).UnixMilli() {
+		t.Errorf("Retrieved launch timestamp is wrong: %d", flight.LaunchTimestamp)
+	}
+
+	if len(flight.Turnpoints) != 4 {
+		t.Fatalf("Expected 4 turnpoints, got %d", len(flight.Turnpoints))
+	}
+	tp1 := flight.Turnpoints[1]
+	if tp1.Name != "TP1" || tp1.Time != "15:42:11" || tp1.Distance != 45.93 {
+		t.Errorf("Retrieved turnpoint is wrong: %+v", tp1)
+	}
+	if math.Abs(tp1.Latitude+22.853883) > 1e-6 || math.Abs(tp1.Longitude+51.9812) > 1e-6 {
+		t.Errorf("Retrieved coordinates are wrong: %f, %f", tp1.Latitude, tp1.Longitude)
+	}
+}
+
+func TestParseFlightPageWithoutDetails(t *testing.T) {
+	url := "https://www.xcontest.org/world/en/flights/detail:Claricegomes/5.12.2021/14:23"
+	file, err := os.Open(filepath.Join("testdata", "flight_detail_01.html"))
+	if err != nil {
+		t.Fatalf("Error reading file: %v", err)
+	}
+	defer file.Close()
+
+	flight, err := ParseFlightPage(file, url, "test")
+	if err != nil {
+		t.Fatalf("Error parsing the flight page: %v", err)
+	}
+	if flight.Glider != "" || flight.Points != 0 || len(flight.Turnpoints) != 0 || flight.TakeOffLocation != nil {
+		t.Errorf("Expected no details, got %+v", flight)
+	}
+	// Without time zone, only the local launch time is known.
+	if flight.LaunchLocalTime != "2021-12-05T14:23:00" || flight.LaunchTimestamp != 0 {
+		t.Errorf("Retrieved launch time is wrong: %s (%d)", flight.LaunchLocalTime, flight.LaunchTimestamp)
+	}
+}
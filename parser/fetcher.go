@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"fahy.xyz/xcontestextractor/crawl"
+)
+
+// StatusError is returned when a page is answered with a non-2xx status code.
+type StatusError struct {
+	Url        string
+	StatusCode int
+	// RetryAfter is the delay requested by the server before retrying, if any.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d for url: %s", e.StatusCode, e.Url)
+}
+
+// Renderer returns the HTML of a page rendered by a browser, i.e. after running its javascript.
+type Renderer interface {
+	Render(ctx context.Context, url string) (string, error)
+}
+
+// Fetcher downloads the pages of XContest.
+type Fetcher struct {
+	// Client used for the requests.
+	Client *http.Client
+	// Header added to every request, e.g. the User-Agent.
+	Header http.Header
+	// Policy limiting the rate of requests, no limit if nil.
+	Policy *crawl.Policy
+	// Gazetteer resolving the take-offs whose page has no location, if any.
+	Gazetteer *Gazetteer
+	// Renderer of the pages of the flights, whose details are rendered by javascript.
+	// The pages are downloaded with plain requests if nil.
+	Renderer Renderer
+}
+
+var (
+	defaultFetcher = NewFetcher(nil, nil, nil)
+)
+
+// NewFetcher creates a new instance of the Fetcher.
+//
+// The default HTTP client is used if client is nil.
+func NewFetcher(client *http.Client, header http.Header, policy *crawl.Policy) *Fetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if header == nil {
+		header = http.Header{}
+	}
+	return &Fetcher{Client: client, Header: header, Policy: policy}
+}
+
+// Get requests the given url.
+//
+// If a policy is set, the request waits for its turn and is retried with a backoff when
+// the server throttles it or fails.
+// A StatusError is returned if the status code is not 2xx, the body is then already closed.
+func (f *Fetcher) Get(ctx context.Context, url string) (*http.Response, error) {
+	if f.Policy == nil {
+		return f.do(ctx, url)
+	}
+	host := crawl.Host(url)
+	for attempt := 0; ; attempt++ {
+		if err := f.Policy.Wait(ctx, host); err != nil {
+			return nil, err
+		}
+		response, err := f.do(ctx, url)
+		if err == nil {
+			f.Policy.Success(host)
+			return response, nil
+		}
+		if ctx.Err() != nil || attempt >= f.Policy.MaxRetries() {
+			return nil, err
+		}
+		var statusError *StatusError
+		if errors.As(err, &statusError) {
+			if crawl.Throttled(statusError.StatusCode) {
+				f.Policy.Throttle(host, statusError.RetryAfter)
+				continue
+			}
+			// Client errors will not be solved by retrying.
+			if statusError.StatusCode < 500 {
+				return nil, err
+			}
+		}
+		log.Warningf("Retrying %s after error: %v", url, err)
+		f.Policy.Backoff(host, 0)
+	}
+}
+
+// do executes a single request.
+func (f *Fetcher) do(ctx context.Context, url string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range f.Header {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+	response, err := f.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		// Read the content of the body before closing.
+		_, _ = io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+		return nil, &StatusError{
+			Url:        url,
+			StatusCode: response.StatusCode,
+			RetryAfter: crawl.ParseRetryAfter(response.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+	return response, nil
+}
+
+// GetFlightInfo downloads the page of a flight and extracts its information.
+//
+// The page is rendered by the Renderer if set, the details of the flight being only available in the rendered page.
+func (f *Fetcher) GetFlightInfo(ctx context.Context, url string, source string) (*Flight, error) {
+	var flight *Flight
+	if f.Renderer != nil {
+		page, err := f.Renderer.Render(ctx, url)
+		if err != nil {
+			log.Errorf("Error rendering url: %v", err)
+			return nil, err
+		}
+		if flight, err = ParseFlightPage(strings.NewReader(page), url, source); err != nil {
+			return nil, err
+		}
+	} else {
+		response, err := f.Get(ctx, url)
+		if err != nil {
+			log.Errorf("Error reading url: %v", err)
+			return nil, err
+		}
+		defer response.Body.Close()
+		if flight, err = ParseFlightPage(response.Body, url, source); err != nil {
+			return nil, err
+		}
+	}
+	if flight.TakeOffLocation == nil && f.Gazetteer != nil {
+		if point, ok := f.Gazetteer.Lookup(flight.TakeOff, flight.CountryCode); ok {
+			flight.TakeOffLocation = &point
+		}
+	}
+	return flight, nil
+}
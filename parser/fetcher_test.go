@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fahy.xyz/xcontestextractor/crawl"
+	"github.com/jarcoal/httpmock"
+)
+
+func TestFetcherHeader(t *testing.T) {
+	url := "https://www.xcontest.org/world/en/flights/detail:Nicober/7.11.2021/13:13"
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", url, func(request *http.Request) (*http.Response, error) {
+		if request.Header.Get("User-Agent") != "xcontest-test" {
+			return httpmock.NewStringResponse(403, ""), nil
+		}
+		return httpmock.NewStringResponse(200, ""), nil
+	})
+	fetcher := NewFetcher(nil, http.Header{"User-Agent": []string{"xcontest-test"}}, nil)
+	response, err := fetcher.Get(context.Background(), url)
+	if err != nil {
+		t.Fatalf("Error getting the page: %v", err)
+	}
+	response.Body.Close()
+}
+
+func TestFetcherStatusError(t *testing.T) {
+	url := "https://www.xcontest.org/world/en/flights/detail:Nicober/7.11.2021/13:13"
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", url, httpmock.NewStringResponder(404, "Not Found"))
+	_, err := NewFetcher(nil, nil, nil).GetFlightInfo(context.Background(), url, "test")
+	var statusError *StatusError
+	if !errors.As(err, &statusError) {
+		t.Fatalf("Expected a status error, got: %v", err)
+	}
+	if statusError.StatusCode != 404 {
+		t.Errorf("Status code is wrong: %d", statusError.StatusCode)
+	}
+}
+
+func TestFetcherRetryAfter(t *testing.T) {
+	url := "https://www.xcontest.org/world/en/flights/detail:Nicober/7.11.2021/13:13"
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	calls := 0
+	httpmock.RegisterResponder("GET", url, func(request *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			response := httpmock.NewStringResponse(429, "")
+			response.Header.Set("Retry-After", "0")
+			return response, nil
+		}
+		return httpmock.NewStringResponse(200, ""), nil
+	})
+	policy := crawl.NewPolicy(crawl.Config{MaxRetries: 1, InitialBackoff: time.Millisecond})
+	response, err := NewFetcher(nil, nil, policy).Get(context.Background(), url)
+	if err != nil {
+		t.Fatalf("Error getting the page: %v", err)
+	}
+	response.Body.Close()
+	if calls != 2 {
+		t.Errorf("Number of requests is wrong: %d", calls)
+	}
+}
+
+// fakeRenderer answers the rendered page of a flight saved in a file.
+type fakeRenderer struct {
+	path string
+	urls []string
+}
+
+func (r *fakeRenderer) Render(_ context.Context, url string) (string, error) {
+	r.urls = append(r.urls, url)
+	page, err := os.ReadFile(r.path)
+	return string(page), err
+}
+
+func TestFetcherRenderer(t *testing.T) {
+	url := "https://www.xcontest.org/world/en/flights/detail:Claricegomes/5.12.2021/14:23"
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	renderer := &fakeRenderer{path: filepath.Join("testdata", "flight_detail_rendered.html")}
+	fetcher := NewFetcher(nil, nil, nil)
+	fetcher.Renderer = renderer
+	flight, err := fetcher.GetFlightInfo(context.Background(), url, "test")
+	if err != nil {
+		t.Fatalf("Error getting the flight: %v", err)
+	}
+	// The page is not downloaded with a plain request.
+	if len(renderer.urls) != 1 || renderer.urls[0] != url || httpmock.GetTotalCallCount() != 0 {
+		t.Errorf("Wrong requests: rendered %v, %d plain requests", renderer.urls, httpmock.GetTotalCallCount())
+	}
+	if flight.Club != "Clube de Voo Livre Terra Rica" || flight.Glider != "OZONE Swift 5" || flight.TakeOffLocation == nil {
+		t.Errorf("Missing details of the rendered page: %+v", flight)
+	}
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// GeoPoint is a location, in the object format of the geo_point of ElasticSearch.
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Gazetteer resolves the location of the take-offs from their name.
+type Gazetteer struct {
+	takeOffs map[string]GeoPoint
+}
+
+// gazetteerKey returns the key of a take-off, its name being case-insensitive.
+func gazetteerKey(name string, countryCode string) string {
+	return strings.ToLower(strings.TrimSpace(name)) + "|" + strings.ToUpper(strings.TrimSpace(countryCode))
+}
+
+// ReadGazetteer reads the take-offs of a CSV with the columns `name,country_code,latitude,longitude`.
+//
+// The first line is the header.
+func ReadGazetteer(r io.Reader) (*Gazetteer, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("error reading the header of the gazetteer: %w", err)
+	}
+	gazetteer := &Gazetteer{takeOffs: make(map[string]GeoPoint)}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		lat, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("error converting the latitude of %s: %w", record[0], err)
+		}
+		lon, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("error converting the longitude of %s: %w", record[0], err)
+		}
+		gazetteer.takeOffs[gazetteerKey(record[0], record[1])] = GeoPoint{Lat: lat, Lon: lon}
+	}
+	return gazetteer, nil
+}
+
+// LoadGazetteer reads the take-offs of a CSV file.
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadGazetteer(file)
+}
+
+// Lookup returns the location of the take-off with the given name in the country.
+func (g *Gazetteer) Lookup(name string, countryCode string) (GeoPoint, bool) {
+	point, ok := g.takeOffs[gazetteerKey(name, countryCode)]
+	return point, ok
+}
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestLoadGazetteer(t *testing.T) {
+	gazetteer, err := LoadGazetteer(filepath.Join("testdata", "gazetteer.csv"))
+	if err != nil {
+		t.Fatalf("Error loading the gazetteer: %v", err)
+	}
+	point, ok := gazetteer.Lookup("san felix", "co")
+	if !ok || point != (GeoPoint{Lat: 5.4386, Lon: -75.4336}) {
+		t.Errorf("Retrieved location is wrong: %+v", point)
+	}
+	if _, ok = gazetteer.Lookup("San Felix", "BR"); ok {
+		t.Errorf("Take-off found in the wrong country")
+	}
+	if _, err = ReadGazetteer(strings.NewReader("name,country_code,latitude,longitude\nFiesch,CH,north,8.1353\n")); err == nil {
+		t.Errorf("Invalid gazetteer read")
+	}
+}
+
+func TestGetFlightInfoGazetteer(t *testing.T) {
+	url := "https://www.xcontest.org/world/en/flights/detail:Fayber/5.12.2021/17:01"
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	content, err := os.ReadFile(filepath.Join("testdata", "flight_detail_02.html"))
+	if err != nil {
+		t.Fatalf("Error reading file: %v", err)
+	}
+	httpmock.RegisterResponder("GET", url,
+		httpmock.NewStringResponder(200, string(content)))
+	fetcher := NewFetcher(nil, nil, nil)
+	if fetcher.Gazetteer, err = LoadGazetteer(filepath.Join("testdata", "gazetteer.csv")); err != nil {
+		t.Fatalf("Error loading the gazetteer: %v", err)
+	}
+	flight, err := fetcher.GetFlightInfo(context.Background(), url, "test")
+	if err != nil {
+		t.Fatalf("Error getting flight information: %v", err)
+	}
+	if flight.TakeOffLocation == nil || *flight.TakeOffLocation != (GeoPoint{Lat: 5.4386, Lon: -75.4336}) {
+		t.Errorf("Retrieved take-off location is wrong: %+v", flight.TakeOffLocation)
+	}
+}
+
+func TestGetFlightInfoRenderedLocation(t *testing.T) {
+	url := "https://www.xcontest.org/world/en/flights/detail:Claricegomes/5.12.2021/14:23"
+	fetcher := NewFetcher(nil, nil, nil)
+	fetcher.Renderer = &fakeRenderer{path: filepath.Join("testdata", "flight_detail_rendered.html")}
+	var err error
+	if fetcher.Gazetteer, err = ReadGazetteer(strings.NewReader("name,country_code,latitude,longitude\nTerra Rica,BR,-23,-52\n")); err != nil {
+		t.Fatalf("Error reading the gazetteer: %v", err)
+	}
+	flight, err := fetcher.GetFlightInfo(context.Background(), url, "test")
+	if err != nil {
+		t.Fatalf("Error getting flight information: %v", err)
+	}
+	// The location of the rendered page takes precedence over the gazetteer.
+	if flight.TakeOffLocation == nil || *flight.TakeOffLocation != (GeoPoint{Lat: -23.1452, Lon: -52.3234}) {
+		t.Errorf("Retrieved take-off location is wrong: %+v", flight.TakeOffLocation)
+	}
+}
@@ -1,13 +1,16 @@
 package parser
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"net/http"
+	"io"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
+	"fahy.xyz/xcontestextractor/dates"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/sqooba/go-common/logging"
 )
@@ -15,27 +18,67 @@ import (
 var (
 	log = logging.NewLogger()
 
-	regexTakeoff  = regexp.MustCompile(`⛳ (.*?) \[`)
-	regexCountry  = regexp.MustCompile(`\[([A-Z]{2})\]`)
-	regexDuration = regexp.MustCompile(`⌛ ([0-9:].*?) ∷`)
-	regexSpeed    = regexp.MustCompile(`∷ ø (.*?) km/h ∷`)
-	regexAltitude = regexp.MustCompile(`⊺ (.*?) m`)
+	regexTakeoff   = regexp.MustCompile(`⛳ (.*?) \[`)
+	regexCountry   = regexp.MustCompile(`\[([A-Z]{2})\]`)
+	regexDuration  = regexp.MustCompile(`⌛ ([0-9:].*?) ∷`)
+	regexSpeed     = regexp.MustCompile(`∷ ø (.*?) km/h ∷`)
+	regexAltitude  = regexp.MustCompile(`⊺ (.*?) m`)
+	regexFlightKey = regexp.MustCompile(`(detail:[^/]+/[^/]+/[^/?#]+)`)
+	regexPilot     = regexp.MustCompile(`detail:([^/]+)/`)
 )
 
 // Flight represents a flight.
 type Flight struct {
-	FullName        string  `json:"full_name"`
-	FlightDate      int64   `json:"flight_date"`
-	Distance        float64 `json:"distance"`
-	FlightType      string  `json:"flight_type"`
-	PublicationDate int64   `json:"publication_date"`
-	Url             string  `json:"url"`
-	TakeOff         string  `json:"take_off"`
-	CountryCode     string  `json:"country_code"`
-	AverageSpeed    float64 `json:"average_speed"`
-	FlightDuration  string  `json:"flight_duration"`
-	AltitudeMax     int64   `json:"altitude_max"`
-	ParsingSource   string  `json:"parsing_source"`
+	FullName              string    `json:"full_name"`
+	PilotHandle           string    `json:"pilot_handle"`
+	FlightDate            int64     `json:"flight_date"`
+	Distance              float64   `json:"distance"`
+	FlightType            string    `json:"flight_type"`
+	PublicationDate       int64     `json:"publication_date"`
+	Url                   string    `json:"url"`
+	TakeOff               string    `json:"take_off"`
+	TakeOffLocation       *GeoPoint `json:"take_off_location,omitempty"`
+	CountryCode           string    `json:"country_code"`
+	AverageSpeed          float64   `json:"average_speed"`
+	FlightDuration        string    `json:"flight_duration"` // Displayed duration, e.g. `4:50:58 h`.
+	FlightDurationSeconds int64     `json:"flight_duration_seconds"`
+	AltitudeMax           int64     `json:"altitude_max"`
+	ParsingSource         string    `json:"parsing_source"`
+	// Details only available on the rendered page of the flight.
+	Glider      string      `json:"glider,omitempty"`
+	GliderClass string      `json:"glider_class,omitempty"`
+	Club        string      `json:"club,omitempty"`
+	Points      float64     `json:"points,omitempty"`
+	Multiplier  float64     `json:"multiplier,omitempty"`
+	LaunchTime  string      `json:"launch_time,omitempty"`
+	LandingTime string      `json:"landing_time,omitempty"`
+	Turnpoints  []Turnpoint `json:"turnpoints,omitempty"`
+	TrackUrl    string      `json:"track_url,omitempty"`
+	// Launch time in the time zone of the take-off, e.g. `2021-12-05T14:23:00`.
+	LaunchLocalTime string `json:"launch_local_time,omitempty"`
+	// Offset of the time zone of the take-off, e.g. `-03:00`, and launch time in UTC. Only set if the offset is known.
+	UtcOffset       string `json:"utc_offset,omitempty"`
+	LaunchTimestamp int64  `json:"launch_timestamp,omitempty"`
+	// Statistics of the IGC track, only set if it is downloaded.
+	MaxClimb           float64 `json:"max_climb,omitempty"`
+	ElevationGain      int64   `json:"elevation_gain,omitempty"`
+	MaxTakeOffDistance float64 `json:"max_take_off_distance,omitempty"`
+}
+
+// ParseError is returned when the page of a flight does not have the expected content.
+type ParseError struct {
+	Url string
+	// Content is the raw description of the flight, if any.
+	Content string
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("error parsing flight %s: %v", e.Url, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
 }
 
 // ExtractMatch extracts the first group of the regex if it matches.
@@ -47,74 +90,146 @@ func ExtractMatch(str string, regex *regexp.Regexp) (string, error) {
 	return "", fmt.Errorf("error extracting %s with regex %s", str, regex)
 }
 
+// ExtractFlightKey extracts the key identifying a flight from its url.
+//
+// The key is made of the pilot handle, the date and the time of the flight, e.g. `detail:Nicober/7.11.2021/13:13`.
+func ExtractFlightKey(url string) (string, error) {
+	return ExtractMatch(url, regexFlightKey)
+}
+
+// ExtractPilotHandle extracts the login of the pilot from the url of a flight, e.g. `Nicober`.
+func ExtractPilotHandle(url string) (string, error) {
+	return ExtractMatch(url, regexPilot)
+}
+
+// GetFlightInfo downloads the page of a flight with the default fetcher and extracts its information.
 func GetFlightInfo(url string, source string) (*Flight, error) {
-	flight := Flight{ParsingSource: source}
-	response, err := http.Get(url)
-	if err != nil {
-		log.Errorf("Error reading url: %v", err)
-		return nil, err
-	}
-	log.Tracef("HTTP response: %s", response.Body)
-	defer response.Body.Close()
+	return defaultFetcher.GetFlightInfo(context.Background(), url, source)
+}
 
-	doc, err := goquery.NewDocumentFromReader(response.Body)
+// ParseFlightPage extracts the information of a flight from its page.
+func ParseFlightPage(r io.Reader, url string, source string) (*Flight, error) {
+	flight := Flight{ParsingSource: source}
+	flight.PilotHandle, _ = ExtractPilotHandle(url)
+	doc, err := goquery.NewDocumentFromReader(r)
 	if err != nil {
 		log.Errorf("Error loading HTTP response body: %v", err)
 		return nil, err
 	}
 
 	matches := doc.Find("meta[property*='og:description']")
-	if matches.Length() > 0 {
-		row, _ := matches.First().Attr("content")
-		log.Tracef("Extracted row: %v", row)
-		if flight.TakeOff, err = ExtractMatch(row, regexTakeoff); err != nil {
-			log.Debug("Error parsing take-off, setting as `unknown`")
-			flight.TakeOff = "unknown"
-		}
-		if flight.CountryCode, err = ExtractMatch(row, regexCountry); err != nil {
-			log.Errorf("Error parsing country code: %v", err)
-			return nil, err
-		}
-		if flight.FlightDuration, err = ExtractMatch(row, regexDuration); err != nil {
-			log.Errorf("Error parsing duration: %v", err)
-			return nil, err
-		}
-		if speedMatch, err := ExtractMatch(row, regexSpeed); err != nil {
-			log.Errorf("Error parsing speed: %v", err)
-			return nil, err
-		} else {
-			speed, err := strconv.ParseFloat(speedMatch, 64)
-			if err != nil {
-				log.Errorf("Error converting speed: %v", err)
-				return nil, err
-			}
-			flight.AverageSpeed = speed
+	if matches.Length() == 0 {
+		return nil, &ParseError{Url: url, Err: errors.New("no description on page")}
+	}
+	row, _ := matches.First().Attr("content")
+	log.Tracef("Extracted row: %v", row)
+	if err = parseDescription(row, &flight); err != nil {
+		return nil, &ParseError{Url: url, Content: row, Err: err}
+	}
+	parseDetails(doc, &flight)
+	if err = flight.SetLaunchTime(url, doc.Find("span.XCutcOffset").First().Text()); err != nil {
+		log.Debugf("Error setting the launch time: %v", err)
+	}
+	return &flight, nil
+}
+
+// SetLaunchTime sets the launch time of the flight from its url and the UTC offset of its take-off, e.g. `UTC-03:00`.
+//
+// Without offset, only the local time is set.
+func (flight *Flight) SetLaunchTime(url string, utcOffset string) error {
+	var location *time.Location
+	if utcOffset != "" {
+		var err error
+		if location, err = dates.ParseUtcOffset(utcOffset); err != nil {
+			return err
 		}
-		if altitudeMatch, err := ExtractMatch(row, regexAltitude); err != nil {
-			log.Errorf("Error parsing altitude: %v", err)
-			return nil, err
-		} else {
-			altitude, err := strconv.ParseInt(altitudeMatch, 10, 64)
-			if err != nil {
-				log.Errorf("Error converting altitude: %v", err)
-				return nil, err
-			}
-			flight.AltitudeMax = altitude
+	}
+	launch, err := dates.LaunchTime(url, location)
+	if err != nil {
+		return err
+	}
+	flight.LaunchLocalTime = launch.Format(dates.LocalTimeLayout)
+	if location != nil {
+		flight.UtcOffset = location.String()
+		flight.LaunchTimestamp = launch.UnixMilli()
+	}
+	return nil
+}
+
+// parseDescription extracts the information of the description of a flight.
+func parseDescription(row string, flight *Flight) error {
+	var err error
+	if flight.TakeOff, err = ExtractMatch(row, regexTakeoff); err != nil {
+		log.Debug("Error parsing take-off, setting as `unknown`")
+		flight.TakeOff = "unknown"
+	}
+	if flight.CountryCode, err = ExtractMatch(row, regexCountry); err != nil {
+		log.Errorf("Error parsing country code: %v", err)
+		return err
+	}
+	if flight.FlightDuration, err = ExtractMatch(row, regexDuration); err != nil {
+		log.Errorf("Error parsing duration: %v", err)
+		return err
+	}
+	if flight.FlightDurationSeconds, err = ParseDuration(flight.FlightDuration); err != nil {
+		log.Errorf("Error converting duration: %v", err)
+		return err
+	}
+	if speedMatch, err := ExtractMatch(row, regexSpeed); err != nil {
+		log.Errorf("Error parsing speed: %v", err)
+		return err
+	} else {
+		speed, err := strconv.ParseFloat(speedMatch, 64)
+		if err != nil {
+			log.Errorf("Error converting speed: %v", err)
+			return err
 		}
-		return &flight, nil
+		flight.AverageSpeed = speed
+	}
+	if altitudeMatch, err := ExtractMatch(row, regexAltitude); err != nil {
+		log.Errorf("Error parsing altitude: %v", err)
+		return err
 	} else {
-		return nil, fmt.Errorf("no match on page for url: %s", url)
+		altitude, err := strconv.ParseInt(altitudeMatch, 10, 64)
+		if err != nil {
+			log.Errorf("Error converting altitude: %v", err)
+			return err
+		}
+		flight.AltitudeMax = altitude
 	}
+	return nil
 }
 
 // ParseDate parse a date using multiple formats.
 func ParseDate(input string) (time.Time, error) {
-	flightDateLayouts := [2]string{"02.01.2006", "2.01.2006"}
-	for _, format := range flightDateLayouts {
-		t, err := time.Parse(format, input)
-		if err == nil {
-			return t, nil
+	return dates.ParseFlightDate(input)
+}
+
+// ParseDuration converts a duration displayed by XContest into seconds.
+//
+// The durations are either in hours, e.g. `4:50:58 h`, or in minutes, e.g. `21:00 min`.
+// Without unit, the first part is the number of hours.
+func ParseDuration(input string) (int64, error) {
+	value, unit, _ := strings.Cut(strings.TrimSpace(input), " ")
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("unrecognized duration format: %s", input)
+	}
+	// Number of seconds of each part, starting with the first one.
+	units := []int64{3600, 60, 1}
+	if unit == "min" {
+		if len(parts) > 2 {
+			return 0, fmt.Errorf("unrecognized duration format: %s", input)
+		}
+		units = units[1:]
+	}
+	var seconds int64
+	for i, part := range parts {
+		number, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("error converting duration %s: %w", input, err)
 		}
+		seconds += number * units[i]
 	}
-	return time.Time{}, errors.New("unrecognized time format")
+	return seconds, nil
 }
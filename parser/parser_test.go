@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -27,6 +28,12 @@ func TestGetFlightInfo01(t *testing.T) {
 	if flight.CountryCode != "BR" {
 		t.Errorf("Retrieved country code is wrong: %s", flight.CountryCode)
 	}
+	if flight.PilotHandle != "Claricegomes" {
+		t.Errorf("Retrieved pilot handle is wrong: %s", flight.PilotHandle)
+	}
+	if flight.FlightDuration != "4:50:58 h" || flight.FlightDurationSeconds != 17458 {
+		t.Errorf("Retrieved duration is wrong: %s (%d s)", flight.FlightDuration, flight.FlightDurationSeconds)
+	}
 }
 
 func TestGetFlightInfo02(t *testing.T) {
@@ -97,3 +104,68 @@ func TestParseDate02(t *testing.T) {
 		t.Errorf("Parsing date is wrong: %s != %s", result, date)
 	}
 }
+
+func TestExtractFlightKey(t *testing.T) {
+	url := "https://www.xcontest.org/world/en/flights/detail:Nicober/7.11.2021/13:13"
+
+	key, err := ExtractFlightKey(url)
+	if err != nil {
+		t.Errorf("Error extracting the flight key: %v", err)
+	}
+	if key != "detail:Nicober/7.11.2021/13:13" {
+		t.Errorf("Extracted flight key is wrong: %s", key)
+	}
+	if _, err = ExtractFlightKey("https://www.xcontest.org/world/en/flights/"); err == nil {
+		t.Errorf("Flight key extracted from an url without detail")
+	}
+}
+
+func TestExtractPilotHandle(t *testing.T) {
+	handle, err := ExtractPilotHandle("https://www.xcontest.org/world/en/flights/detail:Claricegomes/5.12.2021/14:23")
+	if err != nil {
+		t.Errorf("Error extracting the pilot handle: %v", err)
+	}
+	if handle != "Claricegomes" {
+		t.Errorf("Extracted pilot handle is wrong: %s", handle)
+	}
+}
+
+func TestGetFlightInfoParseError(t *testing.T) {
+	url := "https://www.xcontest.org/world/en/flights/detail:Nicober/7.11.2021/13:13"
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	content := `<html><head><meta property="og:description" content="PARAGLIDING &#9971; Fiesch &#8759; ?"/></head></html>`
+	httpmock.RegisterResponder("GET", url,
+		httpmock.NewStringResponder(200, content))
+	_, err := GetFlightInfo(url, "test")
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("Expected a parse error, got: %v", err)
+	}
+	if parseError.Content != "PARAGLIDING ⛳ Fiesch ∷ ?" {
+		t.Errorf("Raw content of the error is wrong: %s", parseError.Content)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]int64{
+		"4:50:58 h": 17458,
+		"21:00 min": 1260,
+		"15:54 min": 954,
+		"1:05 h":    3900,
+		"2:00:00":   7200,
+	}
+	for input, expected := range tests {
+		result, err := ParseDuration(input)
+		if err != nil {
+			t.Errorf("Error parsing the duration %s: %v", input, err)
+		}
+		if result != expected {
+			t.Errorf("Parsed duration of %s is wrong: %d != %d", input, result, expected)
+		}
+	}
+	if _, err := ParseDuration("1:2:3:4 h"); err == nil {
+		t.Errorf("Invalid duration parsed")
+	}
+}
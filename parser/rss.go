@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"fahy.xyz/xcontestextractor/dates"
+)
+
+var (
+	// Regex to parse the title of the items, e.g. `07.11.21 [2.47 km :: free_flight] Nicolas Berardini`.
+	regexDistance   = regexp.MustCompile(`\[(\d+\.\d+) km`)
+	regexFlightType = regexp.MustCompile(`:: (\w+)]`)
+	regexFullName   = regexp.MustCompile(`\] (.*)`)
+)
+
+// rssFeed represents the RSS feed of XContest.
+type rssFeed struct {
+	Channel struct {
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// RSSItem represents a flight of the RSS feed.
+type RSSItem struct {
+	FullName        string
+	FlightDate      time.Time
+	Distance        float64
+	FlightType      string
+	Link            string
+	PublicationDate time.Time
+}
+
+// Entry returns the item as an entry of the archive.
+func (item RSSItem) Entry() Entry {
+	return Entry{
+		FullName:   item.FullName,
+		FlightDate: item.FlightDate.UnixMilli(),
+		Distance:   item.Distance,
+		FlightType: item.FlightType,
+		Link:       item.Link,
+	}
+}
+
+// parseTitle extracts the flight information from the title of an item.
+func parseTitle(title string, item *RSSItem) error {
+	var err error
+	if item.FullName, err = ExtractMatch(title, regexFullName); err != nil {
+		return fmt.Errorf("error getting full name: %w", err)
+	}
+	distanceMatch, err := ExtractMatch(title, regexDistance)
+	if err != nil {
+		return fmt.Errorf("error getting distance: %w", err)
+	}
+	if item.Distance, err = strconv.ParseFloat(distanceMatch, 64); err != nil {
+		return fmt.Errorf("error converting distance flight to float: %w", err)
+	}
+	if item.FlightType, err = ExtractMatch(title, regexFlightType); err != nil {
+		return fmt.Errorf("error getting flight type: %w", err)
+	}
+	if item.FlightDate, err = dates.ParseFlightDate(strings.Split(title, " ")[0]); err != nil {
+		return fmt.Errorf("error converting date flight to timestamp: %w", err)
+	}
+	return nil
+}
+
+// ParseRSS parses the items of the RSS feed of XContest.
+//
+// Items which cannot be parsed are skipped and reported in a RowErrors error,
+// the valid items are always returned.
+func ParseRSS(r io.Reader) ([]RSSItem, error) {
+	var feed rssFeed
+	if err := xml.NewDecoder(r).Decode(&feed); err != nil {
+		return nil, err
+	}
+	var (
+		items     []RSSItem
+		rowErrors RowErrors
+	)
+	for i, entry := range feed.Channel.Items {
+		item := RSSItem{Link: entry.Link}
+		err := parseTitle(entry.Title, &item)
+		if err == nil {
+			item.PublicationDate, err = dates.ParsePubDate(entry.PubDate)
+			if err != nil {
+				err = fmt.Errorf("error converting publication date to timestamp: %w", err)
+			}
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, RowError{Row: i + 1, Link: entry.Link, Err: err})
+			continue
+		}
+		log.Debugf("Parsed item: %+v", item)
+		items = append(items, item)
+	}
+	if len(rowErrors) > 0 {
+		return items, rowErrors
+	}
+	return items, nil
+}
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRSS(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "response.xml"))
+	if err != nil {
+		t.Fatalf("Error reading file: %v", err)
+	}
+	defer file.Close()
+
+	items, err := ParseRSS(file)
+	if err != nil {
+		t.Fatalf("Error parsing the RSS feed: %v", err)
+	}
+	if len(items) != 20 {
+		t.Fatalf("Number of extracted items is wrong: %d", len(items))
+	}
+	expected := RSSItem{
+		FullName:        "Nicolas Berardini",
+		FlightDate:      time.Date(2021, 11, 7, 0, 0, 0, 0, time.UTC),
+		Distance:        2.47,
+		FlightType:      "free_flight",
+		Link:            "https://www.xcontest.org/world/en/flights/detail:Nicober/7.11.2021/13:13",
+		PublicationDate: time.Date(2021, 11, 13, 14, 20, 13, 0, time.UTC),
+	}
+	if items[0] != expected {
+		t.Errorf("Extracted item is wrong: %+v", items[0])
+	}
+	if items[5].FlightType != "fai_triangle" {
+		t.Errorf("Extracted flight type is wrong: %s", items[5].FlightType)
+	}
+}
+
+func TestParseRSSInvalidItem(t *testing.T) {
+	feed := `<rss><channel>
+		<item>
+			<title>13.11.21 [2.17 km :: free_flight] Petar Panic</title>
+			<link>https://www.xcontest.org/world/en/flights/detail:Petar83/13.11.2021/14:12</link>
+			<pubDate>Sat, 13 Nov 2021 14:17:10 +0000</pubDate>
+		</item>
+		<item>
+			<title>13.11.21 [unknown] Petar Panic</title>
+			<link>https://www.xcontest.org/world/en/flights/detail:Petar83/13.11.2021/15:12</link>
+			<pubDate>Sat, 13 Nov 2021 15:17:10 +0000</pubDate>
+		</item>
+	</channel></rss>`
+
+	items, err := ParseRSS(strings.NewReader(feed))
+	var rowErrors RowErrors
+	if !errors.As(err, &rowErrors) {
+		t.Fatalf("Expected row errors, got: %v", err)
+	}
+	if len(rowErrors) != 1 || rowErrors[0].Row != 2 {
+		t.Errorf("Row errors are wrong: %v", rowErrors)
+	}
+	if len(items) != 1 {
+		t.Errorf("Number of extracted items is wrong: %d", len(items))
+	}
+}
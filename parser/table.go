@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"fahy.xyz/xcontestextractor/dates"
+	"golang.org/x/net/html"
+)
+
+// Entry represents a flight of the archive.
+type Entry struct {
+	FullName   string  `json:"full_name"`
+	FlightDate int64   `json:"flight_date"`
+	Distance   float64 `json:"distance"`
+	FlightType string  `json:"flight_type"`
+	Link       string  `json:"link"`
+	// Offset of the time zone of the take-off, e.g. `-03:00`.
+	UtcOffset string `json:"utc_offset,omitempty"`
+}
+
+// RowError is the error of a single row which could not be parsed.
+type RowError struct {
+	Row  int
+	Link string
+	Err  error
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("row %d (%s): %v", e.Row, e.Link, e.Err)
+}
+
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+// RowErrors gathers the errors of all the rows which could not be parsed.
+type RowErrors []RowError
+
+func (e RowErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, rowError := range e {
+		messages[i] = rowError.Error()
+	}
+	return fmt.Sprintf("%d rows with errors: %s", len(e), strings.Join(messages, "; "))
+}
+
+// getAttr returns the value of the attribute with the given name.
+func getAttr(token html.Token, name string) (string, bool) {
+	for _, attr := range token.Attr {
+		if attr.Key == name {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// nextText returns the first non-empty text following the current token.
+func nextText(tokenizer *html.Tokenizer) string {
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.TextToken:
+			if text := strings.TrimSpace(string(tokenizer.Text())); text != "" {
+				return text
+			}
+		}
+	}
+}
+
+// ParseFlightsTable parses the rows of the flights table of the archive.
+//
+// The input can either be the full page or only the body of the table.
+// Rows which cannot be parsed are skipped and reported in a RowErrors error,
+// the valid entries are always returned.
+func ParseFlightsTable(r io.Reader) ([]Entry, error) {
+	var (
+		entries   []Entry
+		rowErrors RowErrors
+		entry     Entry
+		rowErr    error
+		hasCells  bool
+	)
+	row := 0
+	tokenizer := html.NewTokenizer(r)
+	// Iterate over all the tags.
+	for {
+		tokenType := tokenizer.Next()
+
+		// If it's an error token, we either reached
+		// the end of the file, or the HTML was malformed.
+		if tokenType == html.ErrorToken {
+			if err := tokenizer.Err(); !errors.Is(err, io.EOF) {
+				return entries, fmt.Errorf("error tokenizing HTML: %w", err)
+			}
+			break
+		}
+		token := tokenizer.Token()
+
+		if tokenType == html.EndTagToken && token.Data == "tr" {
+			// Header rows do not contain any cell.
+			if !hasCells {
+				continue
+			}
+			if rowErr == nil && entry.Link == "" {
+				rowErr = errors.New("missing link of the flight")
+			}
+			if rowErr != nil {
+				rowErrors = append(rowErrors, RowError{Row: row, Link: entry.Link, Err: rowErr})
+				continue
+			}
+			log.Debugf("Parsed entry: %+v", entry)
+			entries = append(entries, entry)
+			continue
+		}
+		if tokenType != html.StartTagToken {
+			continue
+		}
+		class, _ := getAttr(token, "class")
+		switch token.Data {
+		// Start of a new flight.
+		case "tr":
+			row++
+			entry = Entry{}
+			rowErr = nil
+			hasCells = false
+		case "td":
+			hasCells = true
+			// Extract the distance.
+			if class == "km" {
+				text := nextText(tokenizer)
+				distance, err := strconv.ParseFloat(text, 64)
+				if err != nil && rowErr == nil {
+					rowErr = fmt.Errorf("error converting distance %q to float: %w", text, err)
+				}
+				entry.Distance = distance
+			}
+		// Extract the time zone of the take-off.
+		case "span":
+			if class == "XCutcOffset" {
+				text := nextText(tokenizer)
+				location, err := dates.ParseUtcOffset(text)
+				if err != nil {
+					log.Debugf("Error converting the UTC offset %q: %v", text, err)
+					continue
+				}
+				entry.UtcOffset = location.String()
+			}
+		// Extract the full name.
+		case "b":
+			entry.FullName = nextText(tokenizer)
+		// Extract the link of the flight and its date.
+		case "a":
+			if class != "detail" {
+				continue
+			}
+			entry.Link, _ = getAttr(token, "href")
+			split := strings.Split(entry.Link, "/")
+			if len(split) < 2 {
+				if rowErr == nil {
+					rowErr = errors.New("unexpected link format")
+				}
+				continue
+			}
+			date, err := ParseDate(split[len(split)-2])
+			if err != nil && rowErr == nil {
+				rowErr = fmt.Errorf("error converting the date: %w", err)
+			}
+			entry.FlightDate = date.UnixMilli()
+		// Extract the type of flight.
+		case "div":
+			if strings.HasPrefix(class, "disc") {
+				title, _ := getAttr(token, "title")
+				entry.FlightType = strings.ToLower(strings.ReplaceAll(title, " ", "_"))
+			}
+		}
+	}
+	if len(rowErrors) > 0 {
+		return entries, rowErrors
+	}
+	return entries, nil
+}
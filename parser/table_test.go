@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseFlightsTableEmptyPage(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "flights_2021.html"))
+	if err != nil {
+		t.Fatalf("Error reading file: %v", err)
+	}
+	defer file.Close()
+
+	// The table is rendered by javascript, the raw page does not contain any flight.
+	entries, err := ParseFlightsTable(file)
+	if err != nil {
+		t.Errorf("Error parsing the flights table: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Flights extracted from an empty page: %d", len(entries))
+	}
+}
+
+func TestParseFlightsTable(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "flights_2021_table.html"))
+	if err != nil {
+		t.Fatalf("Error reading file: %v", err)
+	}
+	defer file.Close()
+
+	entries, err := ParseFlightsTable(file)
+	var rowErrors RowErrors
+	if !errors.As(err, &rowErrors) {
+		t.Fatalf("Expected row errors, got: %v", err)
+	}
+	if len(rowErrors) != 1 || rowErrors[0].Row != 5 {
+		t.Errorf("Row errors are wrong: %v", rowErrors)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("Number of extracted flights is wrong: %d", len(entries))
+	}
+	expected := Entry{
+		FullName:   "Clarice Mendes Gomes",
+		FlightDate: time.Date(2021, 12, 5, 0, 0, 0, 0, time.UTC).UnixMilli(),
+		Distance:   103.58,
+		FlightType: "free_flight",
+		Link:       "https://www.xcontest.org/world/en/flights/detail:Claricegomes/5.12.2021/14:23",
+		UtcOffset:  "-03:00",
+	}
+	if entries[0] != expected {
+		t.Errorf("Extracted flight is wrong: %+v", entries[0])
+	}
+	if entries[3].FlightType != "fai_triangle" {
+		t.Errorf("Extracted flight type is wrong: %s", entries[3].FlightType)
+	}
+}
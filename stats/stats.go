@@ -0,0 +1,209 @@
+// Package stats formats the weekly statistics of the flights as JSON, CSV or Markdown.
+package stats
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"fahy.xyz/xcontestextractor/store"
+)
+
+const (
+	// FormatJSON groups the statistics by year, country and week, like the former Python transformer.
+	FormatJSON string = "json"
+	// FormatCSV writes a line by year, week and country.
+	FormatCSV string = "csv"
+	// FormatMarkdown writes a table with a line by year, week and country.
+	FormatMarkdown string = "markdown"
+)
+
+// Columns of the statistics in CSV and Markdown.
+var columns = []string{
+	"year", "week", "country", "flights", "distance_avg", "distance_med",
+	"count_above_50km", "count_above_100km", "count_above_150km", "count_above_200km",
+}
+
+// weekStats are the statistics of a week in JSON, without the keys of the grouping.
+type weekStats struct {
+	DistanceAvg     float64 `json:"distance_avg"`
+	DistanceMed     float64 `json:"distance_med"`
+	CountAbove50km  int64   `json:"count_above_50km"`
+	CountAbove100km int64   `json:"count_above_100km"`
+	CountAbove150km int64   `json:"count_above_150km"`
+	CountAbove200km int64   `json:"count_above_200km"`
+}
+
+// Sort sorts the statistics by year, week and country.
+func Sort(stats []store.WeeklyStats) {
+	sort.SliceStable(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		if a.Year != b.Year {
+			return a.Year < b.Year
+		}
+		if a.Week != b.Week {
+			return a.Week < b.Week
+		}
+		return a.Country < b.Country
+	})
+}
+
+// CheckFormat returns an error if the format is not supported by Write.
+func CheckFormat(format string) error {
+	switch format {
+	case FormatJSON, FormatCSV, FormatMarkdown:
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// Write writes the statistics in the given format.
+func Write(w io.Writer, stats []store.WeeklyStats, format string) error {
+	switch format {
+	case FormatJSON:
+		return WriteJSON(w, stats)
+	case FormatCSV:
+		return WriteCSV(w, stats)
+	case FormatMarkdown:
+		return WriteMarkdown(w, stats)
+	default:
+		return CheckFormat(format)
+	}
+}
+
+// WriteJSON writes the statistics grouped by year, country and week, e.g.
+//
+//	{"2021": {"CH": {"44": {"distance_avg": 40.2, ...}}, "FR": {}}}
+//
+// Every year contains all the countries, the ones without flights being empty. The keys are sorted.
+func WriteJSON(w io.Writer, stats []store.WeeklyStats) error {
+	stats = append([]store.WeeklyStats(nil), stats...)
+	Sort(stats)
+	var countries []string
+	seen := map[string]bool{}
+	for _, stat := range stats {
+		if !seen[stat.Country] {
+			seen[stat.Country] = true
+			countries = append(countries, stat.Country)
+		}
+	}
+	sort.Strings(countries)
+
+	// The objects are written by hand since the keys of the maps of encoding/json are sorted as strings.
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i < len(stats); {
+		year := stats[i].Year
+		end := i
+		for end < len(stats) && stats[end].Year == year {
+			end++
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, `"%d":{`, year)
+		for c, country := range countries {
+			if c > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(country)
+			buf.Write(key)
+			buf.WriteString(":{")
+			first := true
+			for _, stat := range stats[i:end] {
+				if stat.Country != country {
+					continue
+				}
+				if !first {
+					buf.WriteByte(',')
+				}
+				first = false
+				value, err := json.Marshal(weekStats{
+					DistanceAvg:     stat.DistanceAvg,
+					DistanceMed:     stat.DistanceMed,
+					CountAbove50km:  stat.CountAbove50km,
+					CountAbove100km: stat.CountAbove100km,
+					CountAbove150km: stat.CountAbove150km,
+					CountAbove200km: stat.CountAbove200km,
+				})
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(&buf, `"%d":%s`, stat.Week, value)
+			}
+			buf.WriteByte('}')
+		}
+		buf.WriteByte('}')
+		i = end
+	}
+	buf.WriteString("}\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// rows converts the statistics into the values of the columns.
+func rows(stats []store.WeeklyStats, formatFloat func(float64) string) [][]string {
+	result := make([][]string, len(stats))
+	for i, stat := range stats {
+		result[i] = []string{
+			strconv.Itoa(stat.Year),
+			strconv.Itoa(stat.Week),
+			stat.Country,
+			strconv.FormatInt(stat.Flights, 10),
+			formatFloat(stat.DistanceAvg),
+			formatFloat(stat.DistanceMed),
+			strconv.FormatInt(stat.CountAbove50km, 10),
+			strconv.FormatInt(stat.CountAbove100km, 10),
+			strconv.FormatInt(stat.CountAbove150km, 10),
+			strconv.FormatInt(stat.CountAbove200km, 10),
+		}
+	}
+	return result
+}
+
+// WriteCSV writes the statistics with a header and a line by year, week and country, in the given order.
+func WriteCSV(w io.Writer, stats []store.WeeklyStats) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	return writer.WriteAll(rows(stats, func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}))
+}
+
+// WriteMarkdown writes the statistics as a table, in the given order. The distances are rounded to 0.1 km.
+func WriteMarkdown(w io.Writer, stats []store.WeeklyStats) error {
+	var buf bytes.Buffer
+	writeLine := func(values []string) {
+		buf.WriteString("|")
+		for _, value := range values {
+			buf.WriteString(" ")
+			buf.WriteString(value)
+			buf.WriteString(" |")
+		}
+		buf.WriteString("\n")
+	}
+	writeLine(columns)
+	separators := make([]string, len(columns))
+	for i := range separators {
+		// The country is the only column aligned to the left.
+		separators[i] = "---:"
+		if columns[i] == "country" {
+			separators[i] = "---"
+		}
+	}
+	writeLine(separators)
+	for _, row := range rows(stats, func(value float64) string {
+		return strconv.FormatFloat(value, 'f', 1, 64)
+	}) {
+		writeLine(row)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
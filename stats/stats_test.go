@@ -0,0 +1,105 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"fahy.xyz/xcontestextractor/store"
+)
+
+var testStats = []store.WeeklyStats{
+	{Year: 2022, Week: 2, Country: "CH", Flights: 4, DistanceAvg: 61.25, DistanceMed: 12.5, CountAbove50km: 2, CountAbove100km: 1},
+	{Year: 2021, Week: 44, Country: "FR", Flights: 1, DistanceAvg: 12.3, DistanceMed: 0},
+	{Year: 2021, Week: 9, Country: "FR", Flights: 2, DistanceAvg: 155, DistanceMed: 50, CountAbove50km: 2, CountAbove100km: 2, CountAbove150km: 1, CountAbove200km: 1},
+	{Year: 2021, Week: 44, Country: "CH", Flights: 3, DistanceAvg: 40, DistanceMed: 5.5, CountAbove50km: 1},
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, testStats); err != nil {
+		t.Fatalf("Error writing the statistics: %v", err)
+	}
+	expected := `{"2021":{"CH":{"44":{"distance_avg":40,"distance_med":5.5,"count_above_50km":1,"count_above_100km":0,"count_above_150km":0,"count_above_200km":0}},` +
+		`"FR":{"9":{"distance_avg":155,"distance_med":50,"count_above_50km":2,"count_above_100km":2,"count_above_150km":1,"count_above_200km":1},` +
+		`"44":{"distance_avg":12.3,"distance_med":0,"count_above_50km":0,"count_above_100km":0,"count_above_150km":0,"count_above_200km":0}}},` +
+		`"2022":{"CH":{"2":{"distance_avg":61.25,"distance_med":12.5,"count_above_50km":2,"count_above_100km":1,"count_above_150km":0,"count_above_200km":0}},"FR":{}}}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Wrong JSON:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+
+	// The structure is the one of the Python transformer: year, country, week.
+	var decoded map[string]map[string]map[string]map[string]float64
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if decoded["2021"]["FR"]["9"]["count_above_200km"] != 1 {
+		t.Errorf("Wrong structure: %v", decoded)
+	}
+	// The input is not modified.
+	if testStats[0].Year != 2022 {
+		t.Errorf("The statistics were sorted in place")
+	}
+}
+
+func TestWriteJSONEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, nil); err != nil {
+		t.Fatalf("Error writing the statistics: %v", err)
+	}
+	if buf.String() != "{}\n" {
+		t.Errorf("Wrong JSON: %s", buf.String())
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testStats[:2], FormatCSV); err != nil {
+		t.Fatalf("Error writing the statistics: %v", err)
+	}
+	expected := "year,week,country,flights,distance_avg,distance_med,count_above_50km,count_above_100km,count_above_150km,count_above_200km\n" +
+		"2022,2,CH,4,61.25,12.5,2,1,0,0\n" +
+		"2021,44,FR,1,12.3,0,0,0,0,0\n"
+	if buf.String() != expected {
+		t.Errorf("Wrong CSV:\n%s", buf.String())
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testStats[:1], FormatMarkdown); err != nil {
+		t.Fatalf("Error writing the statistics: %v", err)
+	}
+	expected := "| year | week | country | flights | distance_avg | distance_med | count_above_50km | count_above_100km | count_above_150km | count_above_200km |\n" +
+		"| ---: | ---: | --- | ---: | ---: | ---: | ---: | ---: | ---: | ---: |\n" +
+		"| 2022 | 2 | CH | 4 | 61.2 | 12.5 | 2 | 1 | 0 | 0 |\n"
+	if buf.String() != expected {
+		t.Errorf("Wrong Markdown:\n%s", buf.String())
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, testStats, "xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+	if err := CheckFormat("xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+	if err := CheckFormat(FormatMarkdown); err != nil {
+		t.Errorf("Unexpected error for a known format: %v", err)
+	}
+}
+
+func TestSort(t *testing.T) {
+	sorted := append([]store.WeeklyStats(nil), testStats...)
+	Sort(sorted)
+	var keys [][3]interface{}
+	for _, stat := range sorted {
+		keys = append(keys, [3]interface{}{stat.Year, stat.Week, stat.Country})
+	}
+	expected := [][3]interface{}{{2021, 9, "FR"}, {2021, 44, "CH"}, {2021, 44, "FR"}, {2022, 2, "CH"}}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Wrong order: %v", keys)
+	}
+}
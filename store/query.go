@@ -0,0 +1,58 @@
+package store
+
+import (
+	"errors"
+
+	"fahy.xyz/xcontestextractor/parser"
+)
+
+// ErrNotFound is returned when the requested flight or pilot is not stored.
+var ErrNotFound = errors.New("not found")
+
+// FlightQuery filters the flights, the zero values are ignored.
+type FlightQuery struct {
+	// Dates of the flights in milliseconds, both included.
+	From int64
+	To   int64
+	// Country of the take-off, e.g. `CH`.
+	Country string
+	// Type of the flight, e.g. `free_flight`.
+	FlightType string
+	// Login of the pilot, e.g. `Nicober`.
+	PilotHandle string
+	MinDistance float64
+	MaxDistance float64
+	// Pagination of the flights, sorted by date with the latest first.
+	Offset int
+	Size   int
+}
+
+// FlightHit is a stored flight with its id.
+type FlightHit struct {
+	Id string `json:"id"`
+	*parser.Flight
+}
+
+// FlightPage is a page of the flights matching a query.
+type FlightPage struct {
+	// Total number of flights matching the query.
+	Total   int64       `json:"total"`
+	Offset  int         `json:"offset"`
+	Size    int         `json:"size"`
+	Flights []FlightHit `json:"flights"`
+}
+
+// WeeklyStats are the statistics of the distances of the flights of a country during a week.
+type WeeklyStats struct {
+	Year int `json:"year"`
+	// Week of the year, from 1 to 53.
+	Week            int     `json:"week"`
+	Country         string  `json:"country"`
+	Flights         int64   `json:"flights"`
+	DistanceAvg     float64 `json:"distance_avg"`
+	DistanceMed     float64 `json:"distance_med"`
+	CountAbove50km  int64   `json:"count_above_50km"`
+	CountAbove100km int64   `json:"count_above_100km"`
+	CountAbove150km int64   `json:"count_above_150km"`
+	CountAbove200km int64   `json:"count_above_200km"`
+}
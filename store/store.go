@@ -0,0 +1,168 @@
+package store
+
+import (
+	"errors"
+	"strconv"
+
+	"fahy.xyz/xcontestextractor/parser"
+)
+
+const (
+	// BackendElastic stores the flights into ElasticSearch.
+	BackendElastic string = "elastic"
+	// BackendBolt stores the flights into an embedded bbolt database file.
+	BackendBolt string = "bolt"
+)
+
+// ErrFlightExists is returned when inserting a flight which is already stored.
+var ErrFlightExists = errors.New("flight already exists")
+
+// ErrNoPilotHandle is returned when updating the pilot of a flight without pilot handle.
+var ErrNoPilotHandle = errors.New("flight without pilot handle")
+
+// ItemError is the error of a single flight in a batch insertion.
+type ItemError struct {
+	Flight *parser.Flight
+	Err    error
+}
+
+// InsertResult is the outcome of a batch insertion.
+type InsertResult struct {
+	Inserted   []*parser.Flight
+	Duplicates []*parser.Flight
+	Failed     []ItemError
+}
+
+const (
+	// PageComplete is the status of a page whose flights are all stored.
+	PageComplete string = "complete"
+	// PagePartial is the status of a page with flights which failed.
+	PagePartial string = "partial"
+)
+
+// PageState is the status of a processed page of the archive.
+type PageState struct {
+	Start     int    `json:"start"`
+	Status    string `json:"status"`
+	Flights   int    `json:"flights"`
+	Failed    int    `json:"failed"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// FailedFlight is a flight of the archive which could not be stored, kept to be retried.
+type FailedFlight struct {
+	Entry    parser.Entry `json:"entry"`
+	Error    string       `json:"error"`
+	Attempts int          `json:"attempts"`
+}
+
+// DownloadState is the checkpoint of the extraction of a year of the archive.
+//
+// The scope distinguishes the extractions of a subset of the year, e.g. a backfill of a date range,
+// from the extraction of the whole year which has no scope.
+type DownloadState struct {
+	Year             int    `json:"year"`
+	Scope            string `json:"scope,omitempty"`
+	LastFlightNumber int    `json:"last_flight_number"`
+	// Complete is set once all the pages are processed.
+	Complete      bool           `json:"complete,omitempty"`
+	Pages         []PageState    `json:"pages,omitempty"`
+	FailedFlights []FailedFlight `json:"failed_flights,omitempty"`
+}
+
+// StateKey returns the key identifying the checkpoint of a year and a scope, e.g. `2021` or `2021-world`.
+func StateKey(year int, scope string) string {
+	if scope == "" {
+		return strconv.Itoa(year)
+	}
+	return strconv.Itoa(year) + "-" + scope
+}
+
+// Key returns the key identifying the checkpoint.
+func (state *DownloadState) Key() string {
+	return StateKey(state.Year, state.Scope)
+}
+
+// SetPage saves the status of a page, replacing the previous status of the same page.
+func (state *DownloadState) SetPage(page PageState) {
+	for i := range state.Pages {
+		if state.Pages[i].Start == page.Start {
+			state.Pages[i] = page
+			return
+		}
+	}
+	state.Pages = append(state.Pages, page)
+}
+
+// Pilot gathers the flights of a pilot, identified by its login on XContest.
+type Pilot struct {
+	Handle   string `json:"handle"`
+	FullName string `json:"full_name"`
+	// HomeCountry is the country where the pilot flew the most.
+	HomeCountry string `json:"home_country"`
+	// Countries is the number of flights by country.
+	Countries     map[string]int `json:"countries"`
+	FirstSeen     int64          `json:"first_seen"`
+	LastSeen      int64          `json:"last_seen"`
+	FlightCount   int            `json:"flight_count"`
+	TotalDistance float64        `json:"total_distance"`
+}
+
+// NewPilot creates the pilot of a flight, without any flight.
+func NewPilot(flight *parser.Flight) *Pilot {
+	return &Pilot{
+		Handle:      flight.PilotHandle,
+		FullName:    flight.FullName,
+		HomeCountry: flight.CountryCode,
+		Countries:   make(map[string]int),
+		FirstSeen:   flight.FlightDate,
+		LastSeen:    flight.FlightDate,
+	}
+}
+
+// AddFlight updates the statistics of the pilot with a new flight.
+//
+// The name of the pilot is the one of the latest flight.
+func (pilot *Pilot) AddFlight(flight *parser.Flight) {
+	if pilot.Countries == nil {
+		pilot.Countries = make(map[string]int)
+	}
+	pilot.FlightCount++
+	pilot.TotalDistance += flight.Distance
+	if flight.FlightDate < pilot.FirstSeen {
+		pilot.FirstSeen = flight.FlightDate
+	}
+	if flight.FlightDate >= pilot.LastSeen {
+		pilot.LastSeen = flight.FlightDate
+		pilot.FullName = flight.FullName
+	}
+	pilot.Countries[flight.CountryCode]++
+	if pilot.Countries[flight.CountryCode] > pilot.Countries[pilot.HomeCountry] {
+		pilot.HomeCountry = flight.CountryCode
+	}
+}
+
+// FlightStore is the storage used by the extractors to save the flights
+// and the download state of the archive.
+type FlightStore interface {
+	// FlightExists check if the flight with the given url already exist.
+	FlightExists(url string) (bool, error)
+	// InsertFlight insert a single flight.
+	//
+	// ErrFlightExists is returned if the flight is already stored.
+	InsertFlight(flight *parser.Flight) error
+	// InsertFlights insert a batch of flights.
+	//
+	// The error is only set if the whole batch failed, errors of single flights are reported in the result.
+	InsertFlights(flights []*parser.Flight) (InsertResult, error)
+	// UpdatePilots adds newly inserted flights to the statistics of their pilots.
+	//
+	// It must only be called with flights which were just inserted, to count each flight once.
+	UpdatePilots(flights []*parser.Flight) error
+	// GetDownloadState retrieve the checkpoint of the given year and scope, an empty state is returned if there is none.
+	GetDownloadState(year int, scope string) (*DownloadState, error)
+	// SetDownloadState save the checkpoint of a year and scope.
+	SetDownloadState(state *DownloadState) error
+	// Close releases the resources held by the store.
+	Close() error
+}
@@ -0,0 +1,33 @@
+package store
+
+import (
+	"testing"
+
+	"fahy.xyz/xcontestextractor/parser"
+)
+
+func TestPilotAddFlight(t *testing.T) {
+	flights := []*parser.Flight{
+		{PilotHandle: "Nicober", FullName: "Nico Ber", CountryCode: "CH", FlightDate: 2000, Distance: 50},
+		{PilotHandle: "Nicober", FullName: "Nicolas Ber", CountryCode: "FR", FlightDate: 3000, Distance: 100},
+		{PilotHandle: "Nicober", FullName: "Nico B.", CountryCode: "CH", FlightDate: 1000, Distance: 25.5},
+		{PilotHandle: "Nicober", FullName: "Nico Ber", CountryCode: "FR", FlightDate: 1500, Distance: 10},
+		{PilotHandle: "Nicober", FullName: "Nico Ber", CountryCode: "FR", FlightDate: 1600, Distance: 10},
+	}
+	pilot := NewPilot(flights[0])
+	for _, flight := range flights {
+		pilot.AddFlight(flight)
+	}
+	if pilot.FlightCount != 5 || pilot.TotalDistance != 195.5 {
+		t.Errorf("Pilot statistics are wrong: %+v", pilot)
+	}
+	if pilot.FirstSeen != 1000 || pilot.LastSeen != 3000 {
+		t.Errorf("Pilot dates are wrong: %d, %d", pilot.FirstSeen, pilot.LastSeen)
+	}
+	if pilot.FullName != "Nicolas Ber" {
+		t.Errorf("Pilot name is not the latest one: %s", pilot.FullName)
+	}
+	if pilot.HomeCountry != "FR" {
+		t.Errorf("Pilot home country is wrong: %s", pilot.HomeCountry)
+	}
+}